@@ -12,22 +12,45 @@ import (
 	"github.com/gopxl/beep/wav"
 )
 
+// ReturnPlayer decodes a song by what its contents are, falling back to
+// the file extension when the format can't be told from the first bytes.
 func ReturnPlayer(filePath string) (beep.StreamSeekCloser, beep.Format, error) {
-	f, err := os.Open(filePath)
+	format, err := SniffFile(filePath)
 	if err != nil {
 		return nil, beep.Format{}, err
 	}
+	if format == FormatUnknown {
+		format = formatOfExtension(filepath.Ext(filePath))
+	}
+	if !format.Playable() {
+		if format == FormatUnknown {
+			return nil, beep.Format{}, fmt.Errorf("unsupported file format: %s", strings.TrimPrefix(filepath.Ext(filePath), "."))
+		}
+		if !format.isAudio() {
+			return nil, beep.Format{}, fmt.Errorf("not an audio file: got %s", format)
+		}
+		return nil, beep.Format{}, fmt.Errorf("unsupported audio format: %s", format)
+	}
 
-	fileExt := strings.ToLower(strings.TrimPrefix(filepath.Ext(filePath), "."))
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
 
-	switch fileExt {
-	case "wav":
-		return wav.Decode(f)
-	case "mp3":
-		return mp3.Decode(f)
-	case "flac":
-		return flac.Decode(f)
+	var streamer beep.StreamSeekCloser
+	var beepFormat beep.Format
+	switch format {
+	case FormatWAV:
+		streamer, beepFormat, err = wav.Decode(f)
+	case FormatMP3:
+		streamer, beepFormat, err = mp3.Decode(f)
 	default:
-		return nil, beep.Format{}, fmt.Errorf("unsupported file format: %s", fileExt)
+		streamer, beepFormat, err = flac.Decode(f)
+	}
+	if err != nil {
+		// the streamer owns the file once decoding succeeds
+		f.Close()
+		return nil, beep.Format{}, err
 	}
+	return streamer, beepFormat, nil
 }
@@ -0,0 +1,161 @@
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatMP3
+	FormatFLAC
+	FormatWAV
+	FormatOgg
+	FormatM4A
+	FormatZIP
+	FormatHTML
+	FormatJSON
+)
+
+var formatNames = map[Format]string{
+	FormatUnknown: "unknown",
+	FormatMP3:     "mp3",
+	FormatFLAC:    "flac",
+	FormatWAV:     "wav",
+	FormatOgg:     "ogg",
+	FormatM4A:     "m4a",
+	FormatZIP:     "zip",
+	FormatHTML:    "html",
+	FormatJSON:    "json",
+}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// Extension is the file extension for the format, empty when unknown.
+func (f Format) Extension() string {
+	if f == FormatUnknown {
+		return ""
+	}
+	return "." + f.String()
+}
+
+// Playable reports whether ReturnPlayer can decode the format.
+func (f Format) Playable() bool {
+	return f == FormatMP3 || f == FormatFLAC || f == FormatWAV
+}
+
+// isAudio reports whether the format is audio at all, playable or not.
+func (f Format) isAudio() bool {
+	return f.Playable() || f == FormatOgg || f == FormatM4A
+}
+
+// how much of a file Sniff needs to look at
+const sniffLen = 512
+
+// Sniff detects a file's format from its first bytes.
+func Sniff(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return FormatMP3
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return FormatFLAC
+	case len(header) >= 12 && bytes.Equal(header[:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return FormatWAV
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return FormatOgg
+	case len(header) >= 8 && bytes.Equal(header[4:8], []byte("ftyp")):
+		return FormatM4A
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		return FormatZIP
+	case isMPEGFrame(header):
+		return FormatMP3
+	}
+
+	text := bytes.ToLower(bytes.TrimSpace(bytes.TrimPrefix(header, []byte("\xef\xbb\xbf"))))
+	for _, prefix := range []string{"<!doctype html", "<html", "<head", "<body", "<?xml"} {
+		if bytes.HasPrefix(text, []byte(prefix)) {
+			return FormatHTML
+		}
+	}
+	if bytes.HasPrefix(text, []byte("{")) || bytes.HasPrefix(text, []byte("[")) {
+		return FormatJSON
+	}
+	return FormatUnknown
+}
+
+// an MPEG audio frame header starts with 11 set sync bits and a layer other
+// than 0, which would be AAC
+func isMPEGFrame(header []byte) bool {
+	return len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && header[1]&0x06 != 0
+}
+
+func SniffFile(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FormatUnknown, err
+	}
+	defer f.Close()
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return FormatUnknown, err
+	}
+	return Sniff(header[:n]), nil
+}
+
+// VerifyDownload checks a downloaded song before it is saved: error pages are
+// rejected, and the returned file name carries the extension of the format
+// the file really is.
+func VerifyDownload(path string, filename string) (string, error) {
+	format, err := SniffFile(path)
+	if err != nil {
+		return filename, err
+	}
+	switch {
+	case format == FormatHTML:
+		return filename, fmt.Errorf("got an HTML page instead of audio")
+	case format == FormatJSON:
+		return filename, fmt.Errorf("got a JSON response instead of audio")
+	case format == FormatUnknown:
+		return filename, nil
+	}
+
+	ext := filepath.Ext(filename)
+	if strings.EqualFold(ext, format.Extension()) {
+		return filename, nil
+	}
+	// only swap extensions that claim to be some other media format, a
+	// name like "Song v1.2" keeps its dot
+	if known := formatOfExtension(ext); known != FormatUnknown {
+		filename = strings.TrimSuffix(filename, ext)
+	}
+	return filename + format.Extension(), nil
+}
+
+func formatOfExtension(ext string) Format {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	switch ext {
+	case "mp3", "flac", "wav", "ogg", "m4a", "zip", "html", "json":
+		for format, name := range formatNames {
+			if name == ext {
+				return format
+			}
+		}
+	case "mp4", "aac", "alac":
+		return FormatM4A
+	case "opus", "oga":
+		return FormatOgg
+	case "wave":
+		return FormatWAV
+	}
+	return FormatUnknown
+}
@@ -2,17 +2,19 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-	"time"
 	"tracker-tui/audio"
 	"tracker-tui/download"
 	"tracker-tui/filemgmt"
 	"tracker-tui/styles"
 
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/gopxl/beep/speaker"
 )
 
@@ -33,8 +35,7 @@ func startControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.menuFocus = "sheetInput"
 			m.sheetInput.Focus()
 		} else {
-			items, _ := filemgmt.ReturnListOfFiles()
-			m.csvList.SetItems(items)
+			m = showTrackerList(m)
 			m.menuFocus = "list"
 			m.pControlSelect = 1
 		}
@@ -44,58 +45,279 @@ func startControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func listControls(m model, msg tea.KeyMsg) (model, tea.Cmd) {
-	var homeDir string
-	var readErr error
+	if m.csvList.FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.csvList, cmd = m.csvList.Update(msg)
+		return m, cmd
+	}
 	switch msg.String() {
 	case "ctrl+c":
 		return m, tea.Quit
 	case "esc":
+		if m.historyOf != "" {
+			return showTrackerList(m), nil
+		}
 		m.artistChosen = false
 		m.sheetInput.SetValue("")
 		m.menuFocus = "start"
 		m.pControlSelect = 1
 		return m, nil
+	case "h":
+		if m.historyOf != "" || m.csvList.SelectedItem() == nil {
+			break
+		}
+		return showHistory(m, trackerName(m.csvList.SelectedItem())), nil
+	case "r":
+		if m.csvList.SelectedItem() == nil {
+			break
+		}
+		name := m.historyOf
+		if name == "" {
+			name = trackerName(m.csvList.SelectedItem())
+		}
+		return startRefresh(m, name)
+	case "L":
+		lib, err := filemgmt.LoadLibrary()
+		if err != nil {
+			return m, m.csvList.NewStatusMessage("could not load library: " + err.Error())
+		}
+		m.library = lib
+		m.libraryLevel = 0
+		m.libraryCursor = 0
+		m.librarySearching = false
+		m.statusMessage = ""
+		m.menuFocus = "library"
+		return m, nil
+	case "e":
+		if m.historyOf != "" || m.csvList.SelectedItem() == nil {
+			break
+		}
+		return editInfo(m, trackerName(m.csvList.SelectedItem())), textinput.Blink
+	case "p":
+		if m.historyOf == "" {
+			break
+		}
+		removed, err := filemgmt.PruneSnapshots(m.historyOf, filemgmt.CurrentSettings)
+		m = showHistory(m, m.historyOf)
+		if err != nil {
+			return m, m.csvList.NewStatusMessage("prune failed: " + err.Error())
+		}
+		return m, m.csvList.NewStatusMessage(fmt.Sprintf("pruned %d snapshots", len(removed)))
 	case "enter":
-		clear(m.columns)
-		clear(m.rows)
+		if m.csvList.SelectedItem() == nil {
+			return m, nil
+		}
 		index := m.csvList.Index()
 		if _, ok := m.selected[index]; ok {
 			delete(m.selected, index)
 		} else {
 			m.selected[index] = struct{}{}
 		}
-		m.csvChosen = m.csvList.SelectedItem().FilterValue()
+
+		name, snapshotID := trackerName(m.csvList.SelectedItem()), ""
+		if m.historyOf != "" {
+			name = m.historyOf
+			// the newest snapshot is the tracker itself, older ones are read-only
+			if snapshot, ok := m.csvList.SelectedItem().(interface{ Current() bool }); ok && !snapshot.Current() {
+				snapshotID = m.csvList.SelectedItem().FilterValue()
+			}
+		}
+
+		// a tracker that can't be read stays in the list, the rest still open
+		loaded, err := loadTracker(m, name, snapshotID)
+		if err != nil {
+			return m, m.csvList.NewStatusMessage("could not open " + name + ": " + err.Error())
+		}
+		m = loaded
+		m.csvChosen = name
 		m.artistChosen = true
 		m.sheetInput.SetValue("")
 		m.menuFocus = "start"
+		m.csvTableState = false
+		m.pControlSelect = 1
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.csvList, cmd = m.csvList.Update(msg)
+	return m, cmd
+}
 
-		homeDir, _ = os.UserHomeDir()
+func showTrackerList(m model) model {
+	items, _ := filemgmt.ReturnListOfFiles()
+	m.historyOf = ""
+	m.csvList.ResetFilter()
+	m.csvList.SetItems(items)
+	m.csvList.Title = "Browsing " + filemgmt.TrackersDir() + "/"
+	return m
+}
 
-		m.columns, m.rows, readErr = filemgmt.ReadCSVFile(homeDir + "/Documents/tracker-tui/csv/" + m.csvChosen)
-		if readErr != nil {
-			return m, tea.Quit
-		}
+func trackerName(listItem list.Item) string {
+	if named, ok := listItem.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return listItem.FilterValue()
+}
 
-		mainColumns, mainRows, _ := filemgmt.GenerateMainTable(m.columns, m.rows)
+func editInfo(m model, name string) model {
+	m.info, _ = filemgmt.ReadTrackerInfo(name)
+	m.editingInfo = name
+	m.infoInputs[0].SetValue(m.info.Title(name))
+	m.infoInputs[1].SetValue(m.info.Artist)
+	m.infoFocus = 0
+	m.infoInputs[0].Focus()
+	m.infoInputs[1].Blur()
+	m.menuFocus = "editInfo"
+	return m
+}
 
-		m.mainCSVTable.SetColumns(mainColumns)
-		m.mainCSVTable.SetRows(mainRows)
+func editInfoControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.menuFocus = "list"
+		return m, nil
+	case "tab", "shift+tab", "up", "down":
+		m.infoInputs[m.infoFocus].Blur()
+		m.infoFocus = (m.infoFocus + 1) % len(m.infoInputs)
+		m.infoInputs[m.infoFocus].Focus()
+		return m, nil
+	case "enter":
+		m.info.DisplayName = strings.TrimSpace(m.infoInputs[0].Value())
+		m.info.Artist = strings.TrimSpace(m.infoInputs[1].Value())
+		err := filemgmt.WriteTrackerInfo(m.editingInfo, m.info)
+		if err == nil {
+			err = filemgmt.IndexTracker(m.editingInfo)
+		}
+		index := m.csvList.Index()
+		m = showTrackerList(m)
+		m.csvList.Select(index)
+		m.menuFocus = "list"
+		if err != nil {
+			return m, m.csvList.NewStatusMessage("could not save: " + err.Error())
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.infoInputs[m.infoFocus], cmd = m.infoInputs[m.infoFocus].Update(msg)
+	return m, cmd
+}
 
-		m.mainCSVTable.Focus()
-		m.csvTableState = false
-		m.pControlSelect = 1
+func libraryControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := len(m.libraryRows())
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		switch {
+		case m.librarySearching:
+			m.librarySearching = false
+			m.libraryInput.Blur()
+		case m.libraryLevel > 0:
+			m.libraryLevel--
+		default:
+			m.menuFocus = "list"
+		}
+		m.libraryCursor = 0
+		return m, nil
+	case "up", "ctrl+p":
+		if m.libraryCursor > 0 {
+			m.libraryCursor--
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.libraryCursor < rows-1 {
+			m.libraryCursor++
+		}
+		return m, nil
+	case "enter":
+		if m.libraryCursor >= rows {
+			return m, nil
+		}
+		switch {
+		case m.librarySearching:
+			return openLibraryEntry(m, m.libraryResults[m.libraryCursor].LibraryEntry)
+		case m.libraryLevel == 0:
+			m.libraryArtist = m.library.Artists[m.libraryCursor].Name
+			m.libraryLevel = 1
+		case m.libraryLevel == 1:
+			m.libraryEra = m.library.ErasOf(m.libraryArtist)[m.libraryCursor]
+			m.libraryLevel = 2
+		case m.libraryLevel == 2:
+			return openLibraryEntry(m, m.library.EntriesOf(m.libraryEra)[m.libraryCursor])
+		}
+		m.libraryCursor = 0
 		return m, nil
 	}
+
+	if !m.librarySearching {
+		switch msg.String() {
+		case "/":
+			m.librarySearching = true
+			m.libraryCursor = 0
+			m.libraryInput.SetValue("")
+			m.libraryResults = nil
+			return m, m.libraryInput.Focus()
+		case "R":
+			lib, err := filemgmt.RebuildLibrary()
+			if err != nil {
+				m.statusMessage = "rebuild failed: " + err.Error()
+				return m, nil
+			}
+			m.statusMessage = ""
+			m.library = lib
+			m.libraryLevel = 0
+			m.libraryCursor = 0
+		}
+		return m, nil
+	}
+
 	var cmd tea.Cmd
-	m.csvList, cmd = m.csvList.Update(msg)
+	query := m.libraryInput.Value()
+	m.libraryInput, cmd = m.libraryInput.Update(msg)
+	if m.libraryInput.Value() != query {
+		m.libraryResults = filemgmt.SearchLibrary(m.library, m.libraryInput.Value())
+		m.libraryCursor = 0
+	}
 	return m, cmd
 }
 
+// openLibraryEntry opens the tracker an indexed entry belongs to and jumps to
+// its row.
+func openLibraryEntry(m model, entry filemgmt.LibraryEntry) (tea.Model, tea.Cmd) {
+	loaded, err := loadTracker(m, entry.Tracker, "")
+	if err != nil {
+		m.statusMessage = "could not open " + entry.Tracker + ": " + err.Error()
+		return m, nil
+	}
+	m = loaded
+	m.statusMessage = ""
+	m.csvChosen = entry.Tracker
+	for i, tab := range m.tabs {
+		if tab.Tab == entry.Tab && i != m.tabIndex {
+			m = setTab(m, i)
+		}
+	}
+	m.librarySearching = false
+	m.libraryInput.Blur()
+	m.artistChosen = true
+	m.menuFocus = "start"
+	m.pControlSelect = 1
+	return jumpToEntry(m, entry.Entry), tea.ClearScreen
+}
+
+func showHistory(m model, name string) model {
+	items, _ := filemgmt.ReturnListOfSnapshots(name)
+	m.historyOf = name
+	m.csvList.ResetFilter()
+	m.csvList.SetItems(items)
+	m.csvList.Select(0)
+	m.csvList.Title = "History of " + name
+	return m
+}
+
 func sheetInputControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	var homeDir string
 	var cmd tea.Cmd
-	var downloadErr error
-	var readErr error
 	switch msg.String() {
 	case "ctrl+c":
 		return m, tea.Quit
@@ -104,43 +326,23 @@ func sheetInputControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.sheetInput.SetValue("")
 		m.menuFocus = "start"
 		m.pControlSelect = 0
+		m.statusMessage = ""
 		return m, nil
 
 	case "enter":
+		if m.isImporting {
+			return m, nil
+		}
 		if len(m.sheetInput.Value()) > 1 {
-			clear(m.columns)
-			clear(m.rows)
-			url, convertErr := download.ConvertSheetURL(strings.TrimSuffix(m.sheetInput.Value(), "\n"))
+			_, convertErr := download.ParseSheetURL(strings.TrimSuffix(m.sheetInput.Value(), "\n"))
 			if convertErr != nil && convertErr.Error() == "invalid Google Sheets URL" {
 				m.sheetInput.SetValue("")
 				m.sheetInput.Focus()
 				return m, nil
 			}
-			m.sheetInput.SetValue(url)
-			m.csvChosen, downloadErr = download.DownloadFile(m.sheetInput.Value(), "SomeSheet.csv", true)
-			if downloadErr != nil {
-				fmt.Println(downloadErr)
-				panic(downloadErr)
-			}
-
-			m.artistChosen = true
-			m.menuFocus = "start"
-			homeDir, _ = os.UserHomeDir()
-
-			m.columns, m.rows, readErr = filemgmt.ReadCSVFile(homeDir + "/Documents/tracker-tui/csv/" + m.csvChosen)
-			if readErr != nil {
-				return m, tea.Quit
-			}
-
-			mainColumns, mainRows, _ := filemgmt.GenerateMainTable(m.columns, m.rows)
-
-			m.mainCSVTable.SetColumns(mainColumns)
-			m.mainCSVTable.SetRows(mainRows)
-
-			m.mainCSVTable.Focus()
-			m.tableWidth = 44
-			m.pControlSelect = 1
-			return m, nil
+			m.isImporting = true
+			m.statusMessage = ""
+			return m, importSheet(m.sheetInput.Value())
 		}
 	}
 
@@ -188,10 +390,7 @@ func playerControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	case "esc":
 		if m.csvTableState {
-			mainColumns, mainRows, _ := filemgmt.GenerateMainTable(m.columns, m.rows)
-
-			m.mainCSVTable.SetColumns(mainColumns)
-			m.mainCSVTable.SetRows(mainRows)
+			m = refreshMainTable(m)
 			m.csvTableState = false
 			if m.controlState {
 				m.mainCSVTable.Focus()
@@ -200,8 +399,11 @@ func playerControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.tableWidth = 44
 			return m, tea.ClearScreen
 		} else {
-			items, _ := filemgmt.ReturnListOfFiles()
-			m.csvList.SetItems(items)
+			if m.historyOf != "" {
+				m = showHistory(m, m.historyOf)
+			} else {
+				m = showTrackerList(m)
+			}
 
 			m.artistChosen = false
 			m.sheetInput.SetValue("")
@@ -214,43 +416,139 @@ func playerControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, tea.ClearScreen
 		}
 
+	case "[", "]":
+		if len(m.tabs) < 2 {
+			break
+		}
+		index := m.tabIndex + 1
+		if msg.String() == "[" {
+			index = m.tabIndex - 1 + len(m.tabs)
+		}
+		return setTab(m, index%len(m.tabs)), tea.ClearScreen
+	case "r":
+		if m.snapshotID != "" {
+			m.statusMessage = "snapshots are read-only, refresh the current version instead"
+			return m, nil
+		}
+		if m.refreshing != "" {
+			m.statusMessage = "already refreshing " + m.refreshing
+			return m, nil
+		}
+		return startRefresh(m, m.csvChosen)
+	case "n":
+		if m.changes.Refreshed.IsZero() {
+			m.statusMessage = "this tracker hasn't been refreshed yet"
+			return m, nil
+		}
+		m.showChanges = true
+		m.changesCursor = 0
+		return m, nil
+	case "D":
+		m = findDuplicates(m, false)
+		m.showDuplicates = true
+		return m, nil
+	case "i":
+		songs, _ := filemgmt.DownloadedSongs()
+		m.stats = filemgmt.ComputeStats(m.tracker, songs)
+		m.showStats = true
+		m.statsOffset = 0
+		return m, nil
+	case "w":
+		if len(m.tracker.Warnings) == 0 {
+			break
+		}
+		m.showWarnings = true
+		m.warningsOffset = 0
+		return m, nil
+	case "/":
+		m.searching = true
+		m.searchCursor = 0
+		m.searchInput.SetValue("")
+		m.searchResults = nil
+		return m, m.searchInput.Focus()
+	case "F":
+		if !m.csvTableState {
+			break
+		}
+		m.filtering = true
+		m.filterInput.SetValue(m.eraFilter)
+		m.filterInput.CursorEnd()
+		return m, m.filterInput.Focus()
+	case "s":
+		if !m.csvTableState {
+			break
+		}
+		m.eraSort = nextSortField(m.tracker, m.eraSort)
+		m.eraSortDesc = false
+		return refreshEraTable(m), nil
+	case "S":
+		if !m.csvTableState || m.eraSort == noSort {
+			break
+		}
+		m.eraSortDesc = !m.eraSortDesc
+		return refreshEraTable(m), nil
+	case "q":
+		if !m.csvTableState || m.erasTable.Cursor() >= len(m.eraEntries) {
+			break
+		}
+		entry := m.eraEntries[m.erasTable.Cursor()]
+		if _, ok := filemgmt.CachedSongFor(entry.LinkList()); ok {
+			m.statusMessage = filemgmt.FormatTitle(entry.Name) + " is already cached"
+		} else if queueEntry(m, entry, download.PriorityNormal) {
+			m.statusMessage = "queued " + filemgmt.FormatTitle(entry.Name)
+		} else {
+			m.statusMessage = "no downloadable link for " + filemgmt.FormatTitle(entry.Name)
+		}
+		m.downloadItems = m.downloads.Items()
+		return m, nil
+	case "Q":
+		if !m.csvTableState {
+			break
+		}
+		queued := 0
+		for _, entry := range m.eraEntries {
+			if queueEntry(m, entry, download.PriorityLow) {
+				queued++
+			}
+		}
+		m.statusMessage = fmt.Sprintf("queued %d of %d songs", queued, len(m.eraEntries))
+		m.downloadItems = m.downloads.Items()
+		return m, nil
+	case "o":
+		m.showDownloads = true
+		m.downloadItems = m.downloads.Items()
+		m.downloadsCursor = 0
+		return m, nil
+	case "c":
+		m.showCache = true
+		m.cacheCursor = 0
+		return loadCache(m), nil
+	case "a":
+		if !m.csvTableState || m.erasTable.Cursor() >= len(m.eraEntries) {
+			break
+		}
+		return startAnnotating(m, m.eraEntries[m.erasTable.Cursor()])
+	case "l":
+		if !m.csvTableState || len(m.eraEntries) == 0 || m.erasTable.Cursor() >= len(m.eraEntries) {
+			return m, nil
+		}
+		m.linkPickerLinks = m.eraEntries[m.erasTable.Cursor()].LinkList()
+		if len(m.linkPickerLinks) == 0 {
+			m.statusMessage = "this entry has no links"
+			return m, nil
+		}
+		m.linkPicker = true
+		m.linkPickerCursor = 0
+		return m, nil
 	case "enter", " ":
 		if !m.controlState {
 			switch m.pControlSelect {
 			case 0:
-				m.isDownloading = true
-				m.erasTable.MoveUp(1)
-				m.selectedLink = m.erasTable.SelectedRow()[len(m.erasTable.SelectedRow())-1]
-				m.selectedSong = m.erasTable.SelectedRow()
-				parsedLink, convertErr := download.ConvertLink(m.selectedLink)
-				if convertErr != nil {
+				if len(m.eraEntries) == 0 {
 					return m, nil
 				}
-
-				return m, tea.Cmd(func() tea.Msg {
-					fileName, downloadErr := download.DownloadFile(parsedLink, "somesong.mp3", false)
-					if downloadErr != nil {
-						return errMsg{err: downloadErr}
-					}
-
-					homeDir, _ := os.UserHomeDir()
-					fullPath := filepath.Join(homeDir, "Documents", "tracker-tui", "songs", fileName)
-
-					// Wait for file to exist, but still inside a goroutine
-					for {
-						if _, err := os.Stat(fullPath); err == nil {
-							break
-						}
-						time.Sleep(1 * time.Second)
-					}
-
-					decodedFile, fileFormat, songErr := audio.ReturnPlayer(fullPath)
-					if songErr != nil {
-						return errMsg{err: songErr}
-					}
-
-					return audioReadyMsg{stream: decodedFile, format: fileFormat}
-				})
+				m.erasTable.MoveUp(1)
+				return selectSong(m, m.erasTable.Cursor(), "")
 			case 1:
 				if m.isPlaying {
 					speaker.Suspend()
@@ -260,100 +558,922 @@ func playerControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					m.isPlaying = true
 				}
 			case 2:
-				m.isDownloading = true
-				m.erasTable.MoveDown(1)
-				m.selectedLink = m.erasTable.SelectedRow()[len(m.erasTable.SelectedRow())-1]
-				m.selectedSong = m.erasTable.SelectedRow()
-				parsedLink, convertErr := download.ConvertLink(m.selectedLink)
-				if convertErr != nil {
+				if len(m.eraEntries) == 0 {
 					return m, nil
 				}
-
-				return m, tea.Cmd(func() tea.Msg {
-					fileName, downloadErr := download.DownloadFile(parsedLink, "somesong.mp3", false)
-					if downloadErr != nil {
-						return errMsg{err: downloadErr}
-					}
-
-					homeDir, _ := os.UserHomeDir()
-					fullPath := filepath.Join(homeDir, "Documents", "tracker-tui", "songs", fileName)
-
-					// Wait for file to exist, but still inside a goroutine
-					for {
-						if _, err := os.Stat(fullPath); err == nil {
-							break
-						}
-						time.Sleep(1 * time.Second)
-					}
-
-					decodedFile, fileFormat, songErr := audio.ReturnPlayer(fullPath)
-					if songErr != nil {
-						return errMsg{err: songErr}
-					}
-
-					return audioReadyMsg{stream: decodedFile, format: fileFormat}
-				})
+				m.erasTable.MoveDown(1)
+				return selectSong(m, m.erasTable.Cursor(), "")
 			}
 			return m, nil
 		}
 		if m.csvTableState {
-			m.isDownloading = true
-			m.selectedLink = m.erasTable.SelectedRow()[len(m.erasTable.SelectedRow())-1]
-			m.selectedSong = m.erasTable.SelectedRow()
-			parsedLink, convertErr := download.ConvertLink(m.selectedLink)
-			if convertErr != nil {
+			if len(m.eraEntries) == 0 {
 				return m, nil
 			}
-			return m, tea.Cmd(func() tea.Msg {
-				fileName, downloadErr := download.DownloadFile(parsedLink, m.selectedSong[1], false)
-				if downloadErr != nil {
-					return errMsg{err: downloadErr}
-				}
+			return selectSong(m, m.erasTable.Cursor(), "")
+		} else {
+			cursor := m.mainCSVTable.Cursor()
+			if cursor < 0 || cursor >= len(m.tracker.Eras) {
+				return m, nil
+			}
+			return openEra(m, m.tracker.Eras[cursor]), tea.ClearScreen
+		}
 
-				homeDir, _ := os.UserHomeDir()
-				fullPath := filepath.Join(homeDir, "Documents", "tracker-tui", "songs", fileName)
+	}
 
-				// Wait for file to exist, but still inside a goroutine
-				for {
-					if _, err := os.Stat(fullPath); err == nil {
-						break
-					}
-					time.Sleep(1 * time.Second)
-				}
+	if m.csvTableState {
+		m.erasTable, cmd = m.erasTable.Update(msg)
+	} else {
+		m.mainCSVTable, cmd = m.mainCSVTable.Update(msg)
+	}
+	return m, cmd
+}
 
-				decodedFile, fileFormat, songErr := audio.ReturnPlayer(fullPath)
-				if songErr != nil {
-					return errMsg{err: songErr}
-				}
+// loadTracker opens the newest version of a tracker, or one of its past
+// snapshots read-only when snapshotID is set.
+func loadTracker(m model, name string, snapshotID string) (model, error) {
+	var tabs []filemgmt.Tracker
+	var err error
+	dir := filemgmt.SnapshotDir(name, snapshotID)
+	if snapshotID == "" {
+		tabs, err = filemgmt.LoadTracker(name)
+		dir, _ = filemgmt.CurrentSnapshotDir(name)
+	} else {
+		tabs, err = filemgmt.LoadSnapshot(name, snapshotID)
+	}
+	if err != nil {
+		return m, err
+	}
+	m.tabs = tabs
+	m.snapshotID = snapshotID
+	m.info, _ = filemgmt.ReadTrackerInfo(name)
+	m.annotations, _ = filemgmt.ReadAnnotations(name)
+	if m.annotations.Migrate(tabs) {
+		filemgmt.WriteAnnotations(name, m.annotations)
+	}
+	m.changes, _ = filemgmt.ReadChanges(dir)
+	return setTab(m, 0), nil
+}
+
+// startRefresh re-imports a tracker in the background, one at a time.
+func startRefresh(m model, name string) (model, tea.Cmd) {
+	if m.refreshing != "" {
+		return m, m.csvList.NewStatusMessage("already refreshing " + m.refreshing)
+	}
+	m.refreshing = name
+	m.statusMessage = ""
+	return m, refreshSheet(name)
+}
+
+// reloadTracker swaps in the newest version of the open tracker, keeping the
+// tab, era, filter, sort and cursors where they were.
+func reloadTracker(m model) (model, error) {
+	tabName := m.tracker.Tab
+	inEra := m.csvTableState
+	eraCursor := m.mainCSVTable.Cursor()
+	entryCursor := m.erasTable.Cursor()
+	filter, sortField, sortDesc := m.eraFilter, m.eraSort, m.eraSortDesc
+	var eraName string
+	if eraCursor >= 0 && eraCursor < len(m.tracker.Eras) {
+		eraName = m.tracker.Eras[eraCursor].Name
+	}
+	// the selected song, and which of the rows sharing its key it is
+	var entryKey string
+	var entryRepeat int
+	if inEra && entryCursor >= 0 && entryCursor < len(m.eraEntries) {
+		entryKey = m.eraEntries[entryCursor].Key()
+		for _, entry := range m.eraEntries[:entryCursor] {
+			if entry.Key() == entryKey {
+				entryRepeat++
+			}
+		}
+	}
+
+	m, err := loadTracker(m, m.csvChosen, "")
+	if err != nil {
+		return m, err
+	}
+	for i, tab := range m.tabs {
+		if tab.Tab == tabName {
+			m = setTab(m, i)
+			break
+		}
+	}
+
+	eraIndex := min(eraCursor, len(m.tracker.Eras)-1)
+	for i, era := range m.tracker.Eras {
+		if era.Name == eraName {
+			eraIndex = i
+			break
+		}
+	}
+	m.mainCSVTable.SetCursor(max(eraIndex, 0))
+	if inEra && eraIndex >= 0 {
+		m = openEra(m, m.tracker.Eras[eraIndex])
+		m.eraFilter, m.eraSort, m.eraSortDesc = filter, sortField, sortDesc
+		m = refreshEraTable(m)
+		// follow the selected song when rows were added or removed above it
+		cursor := min(entryCursor, max(len(m.eraEntries)-1, 0))
+		repeat := 0
+		for i, entry := range m.eraEntries {
+			if entryKey == "" || entry.Key() != entryKey {
+				continue
+			}
+			// the last repeat there is when the song lost some of them
+			cursor = i
+			if repeat == entryRepeat {
+				break
+			}
+			repeat++
+		}
+		m.erasTable.SetCursor(cursor)
+	}
+	if !m.controlState {
+		m.mainCSVTable.Blur()
+		m.erasTable.Blur()
+	}
+	return m, nil
+}
+
+func setTab(m model, index int) model {
+	m.tabIndex = index
+	m.tracker = m.tabs[index]
+	m.annotationKeys = m.tracker.AnnotationKeys()
+	m.eraAll = nil
+	m.eraEntries = nil
+	m.eraFilter = ""
+	m.csvTableState = false
+	m.tableWidth = 44
+
+	m = refreshMainTable(m)
+	m.mainCSVTable.SetCursor(0)
 
-				return audioReadyMsg{stream: decodedFile, format: fileFormat}
-			})
+	m.mainCSVTable.Focus()
+	m.erasTable.Blur()
+	return m
+}
 
+func selectSong(m model, index int, fallbackFilename string) (model, tea.Cmd) {
+	if index < 0 || index >= len(m.eraEntries) {
+		return m, nil
+	}
+	m.selectedSong = m.eraEntries[index]
+	if fallbackFilename == "" {
+		fallbackFilename = filemgmt.FormatTitle(m.selectedSong.Name)
+	}
+	return playSong(m, m.selectedSong.LinkList(), fallbackFilename)
+}
+
+// playSong plays the song from the cache when one of its links was downloaded
+// before, and downloads it otherwise.
+func playSong(m model, links []string, fallbackFilename string) (model, tea.Cmd) {
+	song, ok := filemgmt.CachedSongFor(links)
+	if !ok {
+		return playLinks(m, links, fallbackFilename)
+	}
+	m.selectedLink = song.Link
+	filemgmt.TouchCachedSong(song.Link)
+	// a cached file that fails to decode is downloaded again
+	m.pendingLinks = links
+	m.pendingFallback = fallbackFilename
+	m.isDownloading = true
+	m.statusMessage = ""
+	return m, decodeSong(song.Path())
+}
+
+// cacheTarget describes the cache record for an entry downloaded from link.
+func cacheTarget(m model, entry filemgmt.Entry, link string) filemgmt.CachedSong {
+	return filemgmt.CachedSong{
+		Link:    link,
+		Tracker: m.csvChosen,
+		Era:     entry.Era,
+		Entry:   entry.Key(),
+		Title:   filemgmt.FormatTitle(entry.Name),
+	}
+}
+
+func cacheSong(id int, path string, target filemgmt.CachedSong) tea.Cmd {
+	return func() tea.Msg {
+		song, err := filemgmt.AddToCache(path, target)
+		return songCachedMsg{id: id, song: song, path: path, err: err}
+	}
+}
+
+// playLinks starts downloading the first usable link, the rest are kept so a
+// failed download can fall back to the next mirror.
+func playLinks(m model, links []string, fallbackFilename string) (model, tea.Cmd) {
+	for len(links) > 0 {
+		link := links[0]
+		links = links[1:]
+		if !download.CanResolve(link) {
+			continue
+		}
+		m.selectedLink = link
+		m.pendingLinks = links
+		m.pendingFallback = fallbackFilename
+		m.isDownloading = true
+		m.statusMessage = ""
+		if item, ok := unfinishedDownload(m, link); ok {
+			// already queued, play it as soon as it is done
+			m.playingDownload = item.ID
+			m.downloads.SetPriority(item.ID, download.PriorityHigh)
+			m.downloads.Resume(item.ID)
 		} else {
-			clear(m.erasColumns)
-			clear(m.erasRows)
-			m.erasColumns, m.erasRows, _ = filemgmt.GenerateEraTable(m.columns, m.rows, m.mainCSVTable.SelectedRow()[1])
-			m.tableWidth = 0
-			for i := range m.erasColumns {
-				m.tableWidth += m.erasColumns[i].Width + 1
+			m.playingDownload = m.downloads.Add(link, filemgmt.IncomingDirFor(link), fallbackFilename, download.PriorityHigh)
+			m.cacheTargets[m.playingDownload] = cacheTarget(m, m.selectedSong, link)
+		}
+		m.downloadItems = m.downloads.Items()
+		return m, nil
+	}
+	m.pendingLinks = nil
+	m.isDownloading = false
+	m.statusMessage = "no playable link for " + filemgmt.FormatTitle(m.selectedSong.Name)
+	return m, nil
+}
+
+func linkPickerControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "l":
+		m.linkPicker = false
+		return m, nil
+	case "up", "k":
+		if m.linkPickerCursor > 0 {
+			m.linkPickerCursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.linkPickerCursor < len(m.linkPickerLinks)-1 {
+			m.linkPickerCursor++
+		}
+		return m, nil
+	case "enter", " ":
+		m.linkPicker = false
+		if len(m.eraEntries) == 0 || m.erasTable.Cursor() >= len(m.eraEntries) {
+			return m, nil
+		}
+		m.selectedSong = m.eraEntries[m.erasTable.Cursor()]
+		// the chosen link goes first, the others stay around as fallbacks
+		links := []string{m.linkPickerLinks[m.linkPickerCursor]}
+		for i, link := range m.linkPickerLinks {
+			if i != m.linkPickerCursor {
+				links = append(links, link)
 			}
-			m.tableWidth = m.tableWidth - 1
+		}
+		return playSong(m, links, filemgmt.FormatTitle(m.selectedSong.Name))
+	}
+	return m, nil
+}
 
-			m.erasTable.SetColumns(m.erasColumns)
-			m.erasTable.SetRows(m.erasRows)
-			m.erasTable.Focus()
-			m.mainCSVTable.Blur()
-			m.csvTableState = true
-			m.erasTable.HelpView()
-			return m, tea.ClearScreen
+// waitForDownload delivers the next change the download manager reports.
+func waitForDownload(downloads *download.Manager) tea.Cmd {
+	return func() tea.Msg {
+		return downloadUpdateMsg(<-downloads.Updates())
+	}
+}
+
+func decodeSong(path string) tea.Cmd {
+	return func() tea.Msg {
+		decodedFile, fileFormat, songErr := audio.ReturnPlayer(path)
+		if songErr != nil {
+			return downloadFailedMsg{err: songErr}
 		}
+		return audioReadyMsg{stream: decodedFile, format: fileFormat}
+	}
+}
 
+// unfinishedDownload finds a download of link that is queued, running or
+// paused. A link is only downloaded once at a time, they share a staging
+// directory.
+func unfinishedDownload(m model, link string) (download.Item, bool) {
+	for _, item := range m.downloads.Items() {
+		if item.URL == link && !item.State.Finished() {
+			return item, true
+		}
 	}
+	return download.Item{}, false
+}
 
-	if m.csvTableState {
-		m.erasTable, cmd = m.erasTable.Update(msg)
-	} else {
-		m.mainCSVTable, cmd = m.mainCSVTable.Update(msg)
+// queueEntry queues the first usable link of an entry without playing it,
+// entries already in the cache are skipped.
+func queueEntry(m model, entry filemgmt.Entry, priority download.Priority) bool {
+	if _, ok := filemgmt.CachedSongFor(entry.LinkList()); ok {
+		return false
+	}
+	for _, link := range entry.LinkList() {
+		if !download.CanResolve(link) {
+			continue
+		}
+		if _, ok := unfinishedDownload(m, link); ok {
+			return true
+		}
+		id := m.downloads.Add(link, filemgmt.IncomingDirFor(link), filemgmt.FormatTitle(entry.Name), priority)
+		m.cacheTargets[id] = cacheTarget(m, entry, link)
+		return true
+	}
+	return false
+}
+
+var downloadStateOrder = map[download.State]int{
+	download.Active:   0,
+	download.Queued:   1,
+	download.Paused:   2,
+	download.Failed:   3,
+	download.Canceled: 4,
+	download.Done:     5,
+}
+
+// downloadRows orders the downloads pane: running first, then the queue by
+// priority, then everything that stopped.
+func (m model) downloadRows() []download.Item {
+	items := make([]download.Item, len(m.downloadItems))
+	copy(items, m.downloadItems)
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].State != items[j].State {
+			return downloadStateOrder[items[i].State] < downloadStateOrder[items[j].State]
+		}
+		return items[i].Priority > items[j].Priority
+	})
+	return items
+}
+
+func downloadsControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.downloadRows()
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if msg.String() == "esc" || msg.String() == "o" {
+		m.showDownloads = false
+		return m, nil
+	}
+	if m.downloadsCursor >= len(items) {
+		return m, nil
+	}
+
+	item := items[m.downloadsCursor]
+	switch msg.String() {
+	case "up", "k":
+		if m.downloadsCursor > 0 {
+			m.downloadsCursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.downloadsCursor < len(items)-1 {
+			m.downloadsCursor++
+		}
+		return m, nil
+	case "p":
+		if item.State == download.Paused {
+			m.downloads.Resume(item.ID)
+		} else {
+			m.downloads.Pause(item.ID)
+		}
+	case "x":
+		m.downloads.Cancel(item.ID)
+	case "r":
+		if item.State == download.Failed || item.State == download.Canceled {
+			m.downloads.Resume(item.ID)
+		}
+	case "+", "=":
+		m.downloads.SetPriority(item.ID, item.Priority+1)
+	case "-":
+		m.downloads.SetPriority(item.ID, item.Priority-1)
+	case "delete", "backspace":
+		m.downloads.Remove(item.ID)
+	case "enter":
+		if item.State != download.Done {
+			return m, nil
+		}
+		m.showDownloads = false
+		m.isDownloading = true
+		m.selectedLink = item.URL
+		if song, ok := filemgmt.CachedSongFor([]string{item.URL}); ok {
+			return m, decodeSong(song.Path())
+		}
+		return m, decodeSong(filepath.Join(item.Dir, item.File))
+	}
+	m.downloadItems = m.downloads.Items()
+	m.downloadsCursor = min(m.downloadsCursor, max(len(m.downloadItems)-1, 0))
+	pruneCacheTargets(m)
+	return m, nil
+}
+
+// pruneCacheTargets forgets the cache records of downloads that were
+// canceled or removed. Failed ones are kept while they can be retried.
+func pruneCacheTargets(m model) {
+	for id := range m.cacheTargets {
+		if item, ok := m.downloads.Get(id); !ok || item.State == download.Canceled {
+			delete(m.cacheTargets, id)
+		}
+	}
+}
+
+// loadCache lists the song cache with the open tracker's songs first.
+func loadCache(m model) model {
+	cache, err := filemgmt.ReadSongCache()
+	if err != nil {
+		m.statusMessage = err.Error()
+	}
+	m.cacheSize = cache.Size()
+	m.cacheRows = cache.Songs()
+	sort.SliceStable(m.cacheRows, func(i, j int) bool {
+		return m.cacheRows[i].Tracker == m.csvChosen && m.cacheRows[j].Tracker != m.csvChosen
+	})
+	m.cacheTrackers = make(map[string]string)
+	for _, song := range m.cacheRows {
+		if _, ok := m.cacheTrackers[song.Tracker]; !ok {
+			info, _ := filemgmt.ReadTrackerInfo(song.Tracker)
+			m.cacheTrackers[song.Tracker] = info.Title(song.Tracker)
+		}
+	}
+	m.cacheCursor = min(m.cacheCursor, max(len(m.cacheRows)-1, 0))
+	return m
+}
+
+func cacheControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "c":
+		m.showCache = false
+		return m, nil
+	}
+	if m.cacheCursor >= len(m.cacheRows) {
+		return m, nil
+	}
+
+	song := m.cacheRows[m.cacheCursor]
+	var err error
+	switch msg.String() {
+	case "up", "k":
+		if m.cacheCursor > 0 {
+			m.cacheCursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.cacheCursor < len(m.cacheRows)-1 {
+			m.cacheCursor++
+		}
+		return m, nil
+	case "enter":
+		m.showCache = false
+		m.isDownloading = true
+		m.selectedLink = song.Link
+		filemgmt.TouchCachedSong(song.Link)
+		return m, decodeSong(song.Path())
+	case "p":
+		err = filemgmt.PinCachedSong(song.Link, !song.Pinned)
+	case "x", "delete", "backspace":
+		err = filemgmt.RemoveCachedSongs(song.Link)
+	case "E":
+		// pinned songs stay, unpin them first to clear them too
+		var links []string
+		for _, other := range m.cacheRows {
+			if other.Tracker == song.Tracker && other.Era == song.Era && !other.Pinned {
+				links = append(links, other.Link)
+			}
+		}
+		err = filemgmt.RemoveCachedSongs(links...)
+		if err == nil {
+			m.statusMessage = fmt.Sprintf("removed %d cached songs from %s", len(links), song.Era)
+		}
+	default:
+		return m, nil
+	}
+	if err != nil {
+		m.statusMessage = err.Error()
+	}
+	return loadCache(m), nil
+}
+
+func openEra(m model, era filemgmt.Era) model {
+	m.eraAll = era.Entries
+	m.eraFilter = ""
+	m.eraSort = noSort
+	m = refreshEraTable(m)
+	m.erasTable.SetCursor(0)
+	m.erasTable.Focus()
+	m.mainCSVTable.Blur()
+	m.csvTableState = true
+	return m
+}
+
+// refreshMainTable rebuilds mainCSVTable, marking eras that changed in the
+// last refresh.
+func refreshMainTable(m model) model {
+	mainColumns, mainRows, _ := filemgmt.GenerateMainTable(m.tracker)
+	changed := m.changes.ChangesFor(m.tracker.Tab)
+	for i, era := range m.tracker.Eras {
+		for _, entry := range era.Entries {
+			if _, ok := changed[entry.Key()]; ok {
+				mainRows[i][1] = "● " + mainRows[i][1]
+				break
+			}
+		}
+	}
+
+	m.mainCSVTable.SetRows(nil)
+	m.mainCSVTable.SetColumns(mainColumns)
+	m.mainCSVTable.SetRows(mainRows)
+	return m
+}
+
+var changeMarkers = map[filemgmt.ChangeKind]string{
+	filemgmt.ChangeLeaked:  "★",
+	filemgmt.ChangeAdded:   "+",
+	filemgmt.ChangeLinks:   "~",
+	filemgmt.ChangeQuality: "~",
+	filemgmt.ChangeType:    "~",
+}
+
+// noSort keeps the era table in sheet order
+const noSort filemgmt.Field = -1
+
+var sortableFields = []filemgmt.Field{
+	filemgmt.FieldName,
+	filemgmt.FieldNotes,
+	filemgmt.FieldTrackLength,
+	filemgmt.FieldFileDate,
+	filemgmt.FieldLeakDate,
+	filemgmt.FieldType,
+	filemgmt.FieldQuality,
+	filemgmt.FieldLinks,
+}
+
+// nextSortField cycles through the columns the tracker has, ending back on
+// sheet order.
+func nextSortField(tracker filemgmt.Tracker, current filemgmt.Field) filemgmt.Field {
+	start := 0
+	for i, f := range sortableFields {
+		if f == current {
+			start = i + 1
+		}
+	}
+	for _, f := range sortableFields[start:] {
+		if tracker.Has(f) {
+			return f
+		}
+	}
+	return noSort
+}
+
+// refreshEraTable rebuilds erasTable from the era's entries, applying the
+// current filter and sort.
+func refreshEraTable(m model) model {
+	m.eraEntries = filemgmt.FilterEntries(m.eraAll, m.eraFilter)
+	if m.eraSort != noSort {
+		m.eraEntries = filemgmt.SortEntries(m.eraEntries, m.eraSort, m.eraSortDesc)
+	}
+	erasColumns, erasRows, _ := filemgmt.GenerateEraTable(m.tracker, m.eraEntries)
+	if changed := m.changes.ChangesFor(m.tracker.Tab); len(changed) > 0 {
+		erasColumns = append([]table.Column{{Title: "Δ", Width: 1}}, erasColumns...)
+		for i, entry := range m.eraEntries {
+			erasRows[i] = append(table.Row{changeMarkers[changed[entry.Key()]]}, erasRows[i]...)
+		}
+	}
+	if m.eraSort != noSort {
+		arrow := " ▲"
+		if m.eraSortDesc {
+			arrow = " ▼"
+		}
+		for i := range erasColumns {
+			if erasColumns[i].Title == m.tracker.Header(m.eraSort) {
+				erasColumns[i].Title += arrow
+				erasColumns[i].Width = max(erasColumns[i].Width, lipgloss.Width(erasColumns[i].Title))
+			}
+		}
+	}
+	if len(m.annotations) > 0 {
+		erasColumns = append(erasColumns, table.Column{Title: "Mine", Width: 12})
+		for i, entry := range m.eraEntries {
+			erasRows[i] = append(erasRows[i], m.annotationFor(entry).Marker())
+		}
+	}
+	m.tableWidth = 0
+	for i := range erasColumns {
+		m.tableWidth += erasColumns[i].Width + 1
+	}
+	m.tableWidth = m.tableWidth - 1
+
+	m.erasTable.SetRows(nil)
+	m.erasTable.SetColumns(erasColumns)
+	m.erasTable.SetRows(erasRows)
+	if m.erasTable.Cursor() >= len(erasRows) {
+		m.erasTable.SetCursor(max(len(erasRows)-1, 0))
+	}
+	return m
+}
+
+// annotationFor looks up the annotation of an entry on the open tab.
+func (m model) annotationFor(entry filemgmt.Entry) filemgmt.Annotation {
+	key, ok := m.annotationKeys[entry.Line]
+	// the playing song may come from another tab with a row on the same line
+	want := m.tracker.Tab + "|" + entry.Key()
+	if !ok || (key != want && !strings.HasPrefix(key, want+"#")) {
+		return filemgmt.Annotation{}
+	}
+	return m.annotations.For(key)
+}
+
+var linkStatuses = []filemgmt.LinkStatus{filemgmt.LinkUnchecked, filemgmt.LinkVerified, filemgmt.LinkBroken}
+
+func startAnnotating(m model, entry filemgmt.Entry) (tea.Model, tea.Cmd) {
+	annotation := m.annotationFor(entry)
+	m.annotating = true
+	m.annotatedEntry = entry
+	m.annotationInputs[0].SetValue(annotation.Note)
+	m.annotationInputs[1].SetValue(strings.Join(annotation.Tags, ", "))
+	m.annotationLink = annotation.Link
+	m.annotationFocus = 0
+	m.annotationInputs[1].Blur()
+	return m, m.annotationInputs[0].Focus()
+}
+
+func annotationControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	fields := len(m.annotationInputs) + 1
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.annotating = false
+		return m, nil
+	case "tab", "shift+tab":
+		if m.annotationFocus < len(m.annotationInputs) {
+			m.annotationInputs[m.annotationFocus].Blur()
+		}
+		if msg.String() == "tab" {
+			m.annotationFocus = (m.annotationFocus + 1) % fields
+		} else {
+			m.annotationFocus = (m.annotationFocus + fields - 1) % fields
+		}
+		if m.annotationFocus < len(m.annotationInputs) {
+			return m, m.annotationInputs[m.annotationFocus].Focus()
+		}
+		return m, nil
+	case "enter":
+		if m.annotations == nil {
+			m.annotations = make(filemgmt.Annotations)
+		}
+		m.annotations.Set(m.annotationKeys[m.annotatedEntry.Line], filemgmt.Annotation{
+			Note: strings.TrimSpace(m.annotationInputs[0].Value()),
+			Tags: filemgmt.ParseTags(m.annotationInputs[1].Value()),
+			Link: m.annotationLink,
+		})
+		m.annotating = false
+		if err := filemgmt.WriteAnnotations(m.csvChosen, m.annotations); err != nil {
+			m.statusMessage = "could not save annotation: " + err.Error()
+		}
+		return refreshEraTable(m), nil
+	}
+
+	if m.annotationFocus == len(m.annotationInputs) {
+		step := 0
+		switch msg.String() {
+		case "left", "h":
+			step = len(linkStatuses) - 1
+		case "right", "l", " ":
+			step = 1
+		}
+		for i, status := range linkStatuses {
+			if status == m.annotationLink {
+				m.annotationLink = linkStatuses[(i+step)%len(linkStatuses)]
+				break
+			}
+		}
+		return m, nil
 	}
+
+	var cmd tea.Cmd
+	m.annotationInputs[m.annotationFocus], cmd = m.annotationInputs[m.annotationFocus].Update(msg)
 	return m, cmd
 }
+
+func filterControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.filtering = false
+		m.filterInput.Blur()
+		m.eraFilter = ""
+		return refreshEraTable(m), nil
+	case "enter":
+		m.filtering = false
+		m.filterInput.Blur()
+		return m, nil
+	}
+
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	if m.filterInput.Value() != m.eraFilter {
+		m.eraFilter = m.filterInput.Value()
+		m = refreshEraTable(m)
+		m.erasTable.SetCursor(0)
+	}
+	return m, cmd
+}
+
+func searchControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.searching = false
+		m.searchInput.Blur()
+		return m, nil
+	case "up", "ctrl+p":
+		if m.searchCursor > 0 {
+			m.searchCursor--
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.searchCursor < len(m.searchResults)-1 {
+			m.searchCursor++
+		}
+		return m, nil
+	case "enter":
+		if m.searchCursor >= len(m.searchResults) {
+			return m, nil
+		}
+		m.searching = false
+		m.searchInput.Blur()
+		return jumpToEntry(m, m.searchResults[m.searchCursor].Entry), tea.ClearScreen
+	}
+
+	query := m.searchInput.Value()
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if m.searchInput.Value() != query {
+		m.searchResults = filemgmt.SearchEntries(m.tracker, m.searchInput.Value())
+		m.searchCursor = 0
+	}
+	return m, cmd
+}
+
+// jumpToEntry opens the entry's era and puts the cursor on its row.
+func jumpToEntry(m model, entry filemgmt.Entry) model {
+	for i, era := range m.tracker.Eras {
+		for _, eraEntry := range era.Entries {
+			if eraEntry.Line != entry.Line {
+				continue
+			}
+			m.mainCSVTable.SetCursor(i)
+			m = openEra(m, era)
+			for row := range m.eraEntries {
+				if m.eraEntries[row].Line == entry.Line {
+					m.erasTable.SetCursor(row)
+				}
+			}
+			return m
+		}
+	}
+	return m
+}
+
+func warningsControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "w":
+		m.showWarnings = false
+		return m, nil
+	case "up", "k":
+		if m.warningsOffset > 0 {
+			m.warningsOffset--
+		}
+	case "down", "j":
+		if m.warningsOffset < len(m.tracker.Warnings)-1 {
+			m.warningsOffset++
+		}
+	}
+	return m, nil
+}
+
+func statsControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "i":
+		m.showStats = false
+		return m, nil
+	case "up", "k":
+		if m.statsOffset > 0 {
+			m.statsOffset--
+		}
+	case "down", "j":
+		if m.statsOffset < len(m.statsLines(statsWidth))-m.statsRows() {
+			m.statsOffset++
+		}
+	}
+	return m, nil
+}
+
+type duplicateRow struct {
+	group int
+	entry filemgmt.DuplicateEntry
+}
+
+// findDuplicates looks for duplicates in the open tracker, or in every
+// tracker in the csv directory when all is set.
+func findDuplicates(m model, all bool) model {
+	trackers := map[string][]filemgmt.Tracker{m.csvChosen: m.tabs}
+	if all {
+		names, _ := filemgmt.TrackerNames()
+		for _, name := range names {
+			if name == m.csvChosen {
+				continue
+			}
+			if tabs, err := filemgmt.LoadTracker(name); err == nil {
+				trackers[name] = tabs
+			}
+		}
+	}
+
+	m.duplicatesAll = all
+	m.duplicates = filemgmt.FindDuplicates(trackers)
+	m.duplicateRows = nil
+	for i, group := range m.duplicates {
+		for _, entry := range group.Entries {
+			m.duplicateRows = append(m.duplicateRows, duplicateRow{group: i, entry: entry})
+		}
+	}
+	m.duplicatesCursor = 0
+	return m
+}
+
+func duplicatesControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "D":
+		m.showDuplicates = false
+		return m, nil
+	case "a":
+		return findDuplicates(m, !m.duplicatesAll), nil
+	case "up", "k":
+		if m.duplicatesCursor > 0 {
+			m.duplicatesCursor--
+		}
+	case "down", "j":
+		if m.duplicatesCursor < len(m.duplicateRows)-1 {
+			m.duplicatesCursor++
+		}
+	case "enter":
+		if m.duplicatesCursor >= len(m.duplicateRows) {
+			return m, nil
+		}
+		dup := m.duplicateRows[m.duplicatesCursor].entry
+		if dup.Tracker != m.csvChosen {
+			loaded, err := loadTracker(m, dup.Tracker, "")
+			if err != nil {
+				m.statusMessage = "could not open " + dup.Tracker + ": " + err.Error()
+				return m, nil
+			}
+			m = loaded
+			m.csvChosen = dup.Tracker
+		}
+		for i, tab := range m.tabs {
+			if tab.Tab == dup.Tab && i != m.tabIndex {
+				m = setTab(m, i)
+			}
+		}
+		m.showDuplicates = false
+		return jumpToEntry(m, dup.Entry), tea.ClearScreen
+	}
+	return m, nil
+}
+
+func changesControls(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "n":
+		m.showChanges = false
+		return m, nil
+	case "up", "k":
+		if m.changesCursor > 0 {
+			m.changesCursor--
+		}
+	case "down", "j":
+		if m.changesCursor < len(m.changes.Changes)-1 {
+			m.changesCursor++
+		}
+	case "enter":
+		if m.changesCursor >= len(m.changes.Changes) {
+			return m, nil
+		}
+		change := m.changes.Changes[m.changesCursor]
+		if change.Kind == filemgmt.ChangeRemoved {
+			return m, nil
+		}
+		for i, tab := range m.tabs {
+			if tab.Tab == change.Tab && i != m.tabIndex {
+				m = setTab(m, i)
+			}
+		}
+		m.showChanges = false
+		return jumpToEntry(m, change.Entry), tea.ClearScreen
+	}
+	return m, nil
+}
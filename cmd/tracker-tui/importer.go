@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"tracker-tui/download"
+	"tracker-tui/filemgmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// importDoneMsg and refreshDoneMsg carry a warning when the import went
+// through but something after it, like indexing, didn't.
+type importDoneMsg struct {
+	name    string
+	warning string
+	err     error
+}
+
+type refreshDoneMsg struct {
+	name    string
+	warning string
+	err     error
+}
+
+func importSheet(input string) tea.Cmd {
+	return func() tea.Msg {
+		name, warning, err := importTracker(input, "")
+		return importDoneMsg{name: name, warning: warning, err: err}
+	}
+}
+
+// refreshSheet re-imports a tracker from the sheet it was imported from.
+func refreshSheet(name string) tea.Cmd {
+	return func() tea.Msg {
+		source := trackerSource(name)
+		if source == "" {
+			return refreshDoneMsg{name: name, err: fmt.Errorf("no source recorded for %s", name)}
+		}
+		_, warning, err := importTracker(source, name)
+		return refreshDoneMsg{name: name, warning: warning, err: err}
+	}
+}
+
+// trackerSource looks the sheet link up in the tracker's info, falling back
+// to the manifest for trackers imported before info was kept.
+func trackerSource(name string) string {
+	if info, err := filemgmt.ReadTrackerInfo(name); err == nil && info.Source != "" {
+		return info.Source
+	}
+	dir, err := filemgmt.CurrentSnapshotDir(name)
+	if err != nil {
+		return ""
+	}
+	manifest, err := filemgmt.ReadManifest(dir)
+	if err != nil {
+		return ""
+	}
+	return manifest.Source
+}
+
+// importTracker downloads every requested tab of a spreadsheet into a new
+// timestamped snapshot of the tracker, alongside a manifest listing the tabs.
+// Unless a name is given, a spreadsheet imported before goes into the same
+// tracker and a new one gets a tracker named by its ID, displayed under the
+// spreadsheet's title.
+func importTracker(input string, name string) (string, string, error) {
+	ref, err := download.ParseSheetURL(input)
+	if err != nil {
+		return "", "", err
+	}
+	if name == "" {
+		name, _ = filemgmt.TrackerForSpreadsheet(ref.ID)
+	}
+
+	tabs := sheetTabs(ref)
+
+	trackersDir := filemgmt.TrackersDir()
+	if err := os.MkdirAll(trackersDir, os.ModePerm); err != nil {
+		return "", "", err
+	}
+	tmpDir, err := os.MkdirTemp(trackersDir, ".import-")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifest := filemgmt.Manifest{Source: strings.TrimSpace(input), SpreadsheetID: ref.ID}
+	var title string
+	for i, tab := range tabs {
+		fileName, err := download.DownloadFileTo(download.SheetExportURL(ref.ID, tab.GID), tmpDir, tab.GID+".csv")
+		if err != nil {
+			return "", "", fmt.Errorf("downloading tab %s: %w", tab.GID, err)
+		}
+
+		// Google names exports "<spreadsheet> - <tab>.csv"
+		sheetName, tabName, _ := strings.Cut(strings.TrimSuffix(fileName, ".csv"), " - ")
+		if title == "" && fileName != tab.GID+".csv" {
+			title = strings.TrimSpace(sheetName)
+		}
+		if tab.Name == "" {
+			tab.Name = strings.TrimSpace(tabName)
+		}
+		if tab.Name == "" {
+			tab.Name = fmt.Sprintf("Tab %d", i+1)
+		}
+
+		file := tab.GID + ".csv"
+		if err := os.Rename(filepath.Join(tmpDir, fileName), filepath.Join(tmpDir, file)); err != nil {
+			return "", "", err
+		}
+		manifest.Tabs = append(manifest.Tabs, filemgmt.Tab{Name: tab.Name, GID: tab.GID, File: file})
+	}
+	if name == "" {
+		name = ref.ID
+	}
+	if title == "" {
+		title = name
+	}
+
+	if err := filemgmt.WriteManifest(tmpDir, manifest); err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	info, err := filemgmt.ReadTrackerInfo(name)
+	if err != nil {
+		info = filemgmt.TrackerInfo{DisplayName: title, Imported: now}
+	}
+	info.Source = manifest.Source
+	info.SpreadsheetID = ref.ID
+	info.GIDs = nil
+	for _, tab := range manifest.Tabs {
+		info.GIDs = append(info.GIDs, tab.GID)
+	}
+	info.Refreshed = now
+
+	current, err := filemgmt.LoadTabs(tmpDir, info.Columns)
+	if err != nil {
+		return "", "", err
+	}
+	// record the detected columns so a wrong guess can be corrected by hand
+	if info.Columns == nil {
+		info.Columns = make(map[string]filemgmt.ColumnMapping)
+	}
+	for _, tab := range current {
+		if _, ok := info.Columns[tab.Tab]; !ok {
+			info.Columns[tab.Tab] = tab.ColumnMapping()
+		}
+	}
+
+	// a tracker that was imported before gets a record of what changed
+	if previous, err := filemgmt.LoadTracker(name); err == nil {
+		if err := filemgmt.WriteChanges(tmpDir, filemgmt.DiffTabs(previous, current)); err != nil {
+			return "", "", err
+		}
+	}
+
+	snapshotDir := filemgmt.SnapshotDir(name, filemgmt.NewSnapshotID(now))
+	if err := os.MkdirAll(filepath.Dir(snapshotDir), os.ModePerm); err != nil {
+		return "", "", err
+	}
+	if err := os.Rename(tmpDir, snapshotDir); err != nil {
+		return "", "", err
+	}
+	if err := filemgmt.WriteTrackerInfo(name, info); err != nil {
+		return name, "", err
+	}
+	// the snapshot is in place, whatever fails from here on is only a warning
+	var warnings []string
+	if err := filemgmt.IndexTracker(name); err != nil {
+		warnings = append(warnings, "library not updated: "+err.Error())
+	}
+	if _, err := filemgmt.PruneSnapshots(name, filemgmt.CurrentSettings); err != nil {
+		warnings = append(warnings, "old snapshots not pruned: "+err.Error())
+	}
+	return name, strings.Join(warnings, "; "), nil
+}
+
+// sheetTabs decides which tabs to import: the gids that were asked for when
+// there are several, otherwise every tab the spreadsheet lists.
+func sheetTabs(ref download.SheetRef) []download.SheetTab {
+	discovered, _ := download.DiscoverSheetTabs(ref.ID)
+	names := make(map[string]string)
+	for _, tab := range discovered {
+		names[tab.GID] = tab.Name
+	}
+
+	if len(ref.GIDs) > 1 || (len(ref.GIDs) == 1 && len(discovered) == 0) {
+		var tabs []download.SheetTab
+		for _, gid := range ref.GIDs {
+			tabs = append(tabs, download.SheetTab{Name: names[gid], GID: gid})
+		}
+		return tabs
+	}
+	if len(discovered) > 0 {
+		return discovered
+	}
+	return []download.SheetTab{{GID: "0"}}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
+	"tracker-tui/audio"
+	"tracker-tui/download"
 	"tracker-tui/filemgmt"
 	"tracker-tui/styles"
 
@@ -16,12 +22,26 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/term"
+	"github.com/dustin/go-humanize"
 	"github.com/gopxl/beep"
 	"github.com/gopxl/beep/speaker"
 )
 
 type errMsg struct{ err error }
+type downloadFailedMsg struct{ err error }
+
+type downloadUpdateMsg download.Item
+
+// songCachedMsg reports a finished download moved into the song cache, path
+// is where it was downloaded to.
+type songCachedMsg struct {
+	id   int
+	song filemgmt.CachedSong
+	path string
+	err  error
+}
 type tickMsg time.Time
 
 type audioReadyMsg struct {
@@ -42,25 +62,90 @@ type model struct {
 	listItems    []list.Item
 	selected     map[int]struct{}
 	csvChosen    string
+	historyOf    string
+	info         filemgmt.TrackerInfo
+	infoInputs   []textinput.Model
+	infoFocus    int
+	editingInfo  string
 
-	mainCSVTable    table.Model
-	erasTable       table.Model
-	columns         []table.Column
-	rows            []table.Row
-	erasColumns     []table.Column
-	erasRows        []table.Row
-	selectedLink    string
-	selectedSong    table.Row
-	csvTableState   bool
-	isDownloading   bool
-	isPlaying       bool
-	decodedFile     beep.StreamSeekCloser
-	fileFormat      beep.Format
-	tableWidth      int
-	controlState    bool
-	pControlSelect  int
-	songProgress    progress.Model
-	downloadSpinner spinner.Model
+	library          filemgmt.Library
+	libraryLevel     int
+	libraryArtist    string
+	libraryEra       filemgmt.LibraryEra
+	libraryCursor    int
+	librarySearching bool
+	libraryInput     textinput.Model
+	libraryResults   []filemgmt.LibraryResult
+
+	mainCSVTable     table.Model
+	erasTable        table.Model
+	tabs             []filemgmt.Tracker
+	tabIndex         int
+	tracker          filemgmt.Tracker
+	eraAll           []filemgmt.Entry
+	eraEntries       []filemgmt.Entry
+	eraFilter        string
+	filterInput      textinput.Model
+	filtering        bool
+	eraSort          filemgmt.Field
+	eraSortDesc      bool
+	searching        bool
+	searchInput      textinput.Model
+	searchResults    []filemgmt.SearchResult
+	searchCursor     int
+	showWarnings     bool
+	warningsOffset   int
+	snapshotID       string
+	changes          filemgmt.TrackerDiff
+	showChanges      bool
+	showStats        bool
+	annotations      filemgmt.Annotations
+	annotationKeys   map[int]string
+	annotating       bool
+	annotatedEntry   filemgmt.Entry
+	annotationInputs []textinput.Model
+	annotationFocus  int
+	annotationLink   filemgmt.LinkStatus
+	showDuplicates   bool
+	duplicatesAll    bool
+	duplicates       []filemgmt.DuplicateGroup
+	duplicateRows    []duplicateRow
+	duplicatesCursor int
+	stats            filemgmt.TrackerStats
+	statsOffset      int
+	changesCursor    int
+	selectedLink     string
+	selectedSong     filemgmt.Entry
+	pendingLinks     []string
+	pendingFallback  string
+	statusMessage    string
+	linkPicker       bool
+	linkPickerLinks  []string
+	linkPickerCursor int
+	csvTableState    bool
+	isDownloading    bool
+	downloads        *download.Manager
+	downloadItems    []download.Item
+	playingDownload  int
+	cacheTargets     map[int]filemgmt.CachedSong
+	showCache        bool
+	cacheSize        int64
+	cacheRows        []filemgmt.CachedSong
+	cacheTrackers    map[string]string
+	cacheCursor      int
+	showDownloads    bool
+	downloadsCursor  int
+	isImporting      bool
+	refreshing       string
+	isPlaying        bool
+	decodedFile      beep.StreamSeekCloser
+	fileFormat       beep.Format
+	tableWidth       int
+	controlState     bool
+	pControlSelect   int
+	songProgress     progress.Model
+	downloadSpinner  spinner.Model
+	downloadProgress progress.Model
 }
 
 func main() {
@@ -68,6 +153,12 @@ func main() {
 		fmt.Printf("theme load error: %v\n", err)
 		os.Exit(1)
 	}
+	if err := filemgmt.InitSettings(); err != nil {
+		fmt.Printf("settings load error: %v\n", err)
+		os.Exit(1)
+	}
+	// the cache limit may have been lowered since the last run
+	filemgmt.FitCacheLimit()
 	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
@@ -76,8 +167,11 @@ func main() {
 
 }
 
+// widest the download progress bar gets, percentage included
+const downloadBarWidth = 50
+
 func initialModel() model {
-	emptyRow := table.Row{"No Song Currently Selected", "", "", "", ""}
+	emptySong := filemgmt.Entry{Name: "No Song Currently Selected"}
 	items, _ := filemgmt.ReturnListOfFiles()
 
 	loadingSpinner := spinner.New()
@@ -89,13 +183,51 @@ func initialModel() model {
 	sheetInput.CharLimit = 200
 	sheetInput.Width = 81
 
+	filterInput := textinput.New()
+	filterInput.Prompt = "filter: "
+	filterInput.Placeholder = "title feat:name prod:name ver:v2 tag:grail"
+	filterInput.CharLimit = 100
+	filterInput.Width = 50
+
+	searchInput := textinput.New()
+	searchInput.Prompt = "search: "
+	searchInput.Placeholder = "title, alt title, feature or notes"
+	searchInput.CharLimit = 100
+	searchInput.Width = 50
+
+	libraryInput := textinput.New()
+	libraryInput.Prompt = "search library: "
+	libraryInput.Placeholder = "title, alt title, feature or notes"
+	libraryInput.CharLimit = 100
+	libraryInput.Width = 50
+
+	noteInput := textinput.New()
+	noteInput.Prompt = "note: "
+	noteInput.CharLimit = 300
+	noteInput.Width = 50
+
+	tagsInput := textinput.New()
+	tagsInput.Prompt = "tags: "
+	tagsInput.Placeholder = "comma separated"
+	tagsInput.CharLimit = 100
+	tagsInput.Width = 50
+
+	displayNameInput := textinput.New()
+	displayNameInput.Prompt = "display name: "
+	displayNameInput.CharLimit = 100
+	displayNameInput.Width = 50
+
+	artistInput := textinput.New()
+	artistInput.Prompt = "artist: "
+	artistInput.CharLimit = 100
+	artistInput.Width = 50
+
 	filesListAdditionalStyles := list.NewDefaultDelegate()
 	filesListAdditionalStyles.Styles.SelectedTitle = styles.ListSelection
 	filesListAdditionalStyles.Styles.SelectedDesc = styles.ListSelection
 
 	filesList := list.New(items, filesListAdditionalStyles, 0, 0)
-	osHomeDir, _ := os.UserHomeDir()
-	filesList.Title = "Browsing " + osHomeDir + "/Documents/tracker-tui/csv/"
+	filesList.Title = "Browsing " + filemgmt.TrackersDir() + "/"
 	filesList.Styles.Title = styles.ListTitle
 	filesList.KeyMap.Quit.SetEnabled(false)
 	filesList.KeyMap.Quit.Unbind()
@@ -115,7 +247,27 @@ func initialModel() model {
 			),
 			key.NewBinding(
 				key.WithKeys("esc"),
-				key.WithHelp("esc", "exit to main menu"),
+				key.WithHelp("esc", "back"),
+			),
+			key.NewBinding(
+				key.WithKeys("h"),
+				key.WithHelp("h", "history"),
+			),
+			key.NewBinding(
+				key.WithKeys("r"),
+				key.WithHelp("r", "refresh"),
+			),
+			key.NewBinding(
+				key.WithKeys("L"),
+				key.WithHelp("L", "library"),
+			),
+			key.NewBinding(
+				key.WithKeys("e"),
+				key.WithHelp("e", "edit name/artist"),
+			),
+			key.NewBinding(
+				key.WithKeys("p"),
+				key.WithHelp("p", "prune history"),
 			),
 		}
 	}
@@ -133,31 +285,43 @@ func initialModel() model {
 	downloadSpinner.Spinner = spinner.Points
 	downloadSpinner.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#8a9a7b"))
 
+	downloads := download.NewManager(3, audio.VerifyDownload)
+	downloads.Start(context.Background())
+
 	return model{
-		selected:        make(map[int]struct{}),
-		artistChosen:    false,
-		sheetInput:      sheetInput,
-		headerStyles:    styles.Header,
-		csvList:         filesList,
-		listItems:       items,
-		menuFocus:       "start",
-		mainCSVTable:    mainCSVTable,
-		erasTable:       erasTable,
-		selectedLink:    "Not Selected yet",
-		csvTableState:   false,
-		isPlaying:       false,
-		selectedSong:    emptyRow,
-		tableWidth:      44,
-		pControlSelect:  1,
-		controlState:    true,
-		songProgress:    progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage()),
-		downloadSpinner: downloadSpinner,
-		isDownloading:   false,
+		downloads:        downloads,
+		cacheTargets:     make(map[int]filemgmt.CachedSong),
+		selected:         make(map[int]struct{}),
+		artistChosen:     false,
+		sheetInput:       sheetInput,
+		filterInput:      filterInput,
+		searchInput:      searchInput,
+		infoInputs:       []textinput.Model{displayNameInput, artistInput},
+		libraryInput:     libraryInput,
+		annotationInputs: []textinput.Model{noteInput, tagsInput},
+		eraSort:          noSort,
+		headerStyles:     styles.Header,
+		csvList:          filesList,
+		listItems:        items,
+		menuFocus:        "start",
+		mainCSVTable:     mainCSVTable,
+		erasTable:        erasTable,
+		selectedLink:     "Not Selected yet",
+		csvTableState:    false,
+		isPlaying:        false,
+		selectedSong:     emptySong,
+		tableWidth:       44,
+		pControlSelect:   1,
+		controlState:     true,
+		songProgress:     progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage()),
+		downloadProgress: progress.New(progress.WithDefaultGradient(), progress.WithWidth(downloadBarWidth)),
+		downloadSpinner:  downloadSpinner,
+		isDownloading:    false,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(m.downloadSpinner.Tick, textinput.Blink)
+	return tea.Batch(m.downloadSpinner.Tick, textinput.Blink, waitForDownload(m.downloads))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -178,6 +342,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch m.artistChosen {
 		case true:
+			if m.annotating {
+				return annotationControls(m, msg)
+			}
+			if m.linkPicker {
+				return linkPickerControls(m, msg)
+			}
+			if m.filtering {
+				return filterControls(m, msg)
+			}
+			if m.searching {
+				return searchControls(m, msg)
+			}
+			if m.showWarnings {
+				return warningsControls(m, msg)
+			}
+			if m.showChanges {
+				return changesControls(m, msg)
+			}
+			if m.showStats {
+				return statsControls(m, msg)
+			}
+			if m.showDuplicates {
+				return duplicatesControls(m, msg)
+			}
+			if m.showDownloads {
+				return downloadsControls(m, msg)
+			}
+			if m.showCache {
+				return cacheControls(m, msg)
+			}
 			return playerControls(m, msg)
 		case false:
 			switch m.menuFocus {
@@ -187,6 +381,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return listControls(m, msg)
 			case "sheetInput":
 				return sheetInputControls(m, msg)
+			case "editInfo":
+				return editInfoControls(m, msg)
+			case "library":
+				return libraryControls(m, msg)
 			}
 		}
 
@@ -197,13 +395,122 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.csvList.SetSize(termWidth, termHeight-4)
 		m.mainCSVTable.SetHeight(termHeight - 3)
 		m.erasTable.SetHeight(termHeight - 3)
+		// the bar sits in the player, next to the tables
+		m.downloadProgress.Width = max(min(termWidth-m.tableWidth-30, downloadBarWidth), 10)
 		if m.decodedFile != nil {
 			fmt.Print(float64(m.decodedFile.Position()) / float64(m.decodedFile.Len()))
 		}
 		return m, nil
 
 	case errMsg:
-		fmt.Println("Error:", msg.err)
+		m.statusMessage = "error: " + msg.err.Error()
+		return m, nil
+
+	case importDoneMsg:
+		m.isImporting = false
+		if msg.err != nil {
+			m.statusMessage = "import failed: " + msg.err.Error()
+			return m, nil
+		}
+		var readErr error
+		m.csvChosen = msg.name
+		m, readErr = loadTracker(m, m.csvChosen, "")
+		if readErr != nil {
+			m.statusMessage = "could not read tracker: " + readErr.Error()
+			return m, nil
+		}
+		m.statusMessage = msg.warning
+		m.sheetInput.SetValue("")
+		m.artistChosen = true
+		m.menuFocus = "start"
+		m.pControlSelect = 1
+		return m, tea.ClearScreen
+
+	case refreshDoneMsg:
+		m.refreshing = ""
+		if msg.err == nil && m.artistChosen && m.csvChosen == msg.name && m.snapshotID == "" {
+			var readErr error
+			m, readErr = reloadTracker(m)
+			if readErr != nil {
+				msg.err = readErr
+			} else {
+				m.statusMessage = fmt.Sprintf("refreshed, %d changes", len(m.changes.Changes))
+				if msg.warning != "" {
+					m.statusMessage += " (" + msg.warning + ")"
+				}
+				return m, tea.ClearScreen
+			}
+		}
+		if msg.err != nil {
+			if m.artistChosen {
+				m.statusMessage = "refresh failed: " + msg.err.Error()
+				return m, nil
+			}
+			return m, m.csvList.NewStatusMessage("refresh failed: " + msg.err.Error())
+		}
+		if !m.artistChosen && m.menuFocus == "list" {
+			index := m.csvList.Index()
+			if m.historyOf != "" {
+				m = showHistory(m, m.historyOf)
+			} else {
+				m = showTrackerList(m)
+				m.csvList.Select(index)
+			}
+		}
+		status := "refreshed " + msg.name
+		if msg.warning != "" {
+			status += " (" + msg.warning + ")"
+		}
+		return m, m.csvList.NewStatusMessage(status)
+
+	case downloadUpdateMsg:
+		m.downloadItems = m.downloads.Items()
+		wait := waitForDownload(m.downloads)
+		pruneCacheTargets(m)
+		if target, ok := m.cacheTargets[msg.ID]; ok && msg.State == download.Done {
+			delete(m.cacheTargets, msg.ID)
+			return m, tea.Batch(wait, cacheSong(msg.ID, filepath.Join(msg.Dir, msg.File), target))
+		}
+		if msg.ID != m.playingDownload {
+			return m, wait
+		}
+		switch msg.State {
+		case download.Done:
+			m.playingDownload = 0
+			return m, tea.Batch(wait, decodeSong(filepath.Join(msg.Dir, msg.File)))
+		case download.Failed:
+			m.playingDownload = 0
+			if len(m.pendingLinks) > 0 {
+				m, cmd = playLinks(m, m.pendingLinks, m.pendingFallback)
+				return m, tea.Batch(wait, cmd)
+			}
+			m.isDownloading = false
+			m.statusMessage = "download failed: " + msg.Err.Error()
+		case download.Canceled:
+			m.playingDownload = 0
+			m.isDownloading = false
+			m.statusMessage = "download canceled"
+		}
+		return m, wait
+
+	case songCachedMsg:
+		path := msg.song.Path()
+		if msg.err != nil {
+			path = msg.path
+			m.statusMessage = "could not cache song: " + msg.err.Error()
+		}
+		if msg.id != m.playingDownload {
+			return m, nil
+		}
+		m.playingDownload = 0
+		return m, decodeSong(path)
+
+	case downloadFailedMsg:
+		if len(m.pendingLinks) > 0 {
+			return playLinks(m, m.pendingLinks, m.pendingFallback)
+		}
+		m.isDownloading = false
+		m.statusMessage = "download failed: " + msg.err.Error()
 		return m, nil
 
 	case audioReadyMsg:
@@ -267,8 +574,21 @@ func (m model) View() string {
 		var downloadSpinner string = ""
 
 		s = styles.Header.Width(m.termWidth).Render("tracker-tui")
-		songName := lipgloss.NewStyle().Foreground(lipgloss.Color("#c4746e")).Height(3).Foreground(lipgloss.Color("#c4746e")).MarginBottom(2).AlignVertical(lipgloss.Center).PaddingLeft(1).PaddingRight(1).Render(filemgmt.FormatTitle(m.selectedSong[0]))
-		artist := lipgloss.NewStyle().MarginBottom(1).Render(strings.Split(m.csvChosen, ".csv")[0])
+		songName := lipgloss.NewStyle().Foreground(lipgloss.Color("#c4746e")).Height(3).Foreground(lipgloss.Color("#c4746e")).MarginBottom(2).AlignVertical(lipgloss.Center).PaddingLeft(1).PaddingRight(1).Render(filemgmt.FormatTitle(m.selectedSong.Name))
+		if credits := renderCredits(m.selectedSong.Title()); credits != "" {
+			songName = lipgloss.JoinVertical(lipgloss.Center, songName, lipgloss.NewStyle().MarginBottom(1).Render(credits))
+		}
+		if annotation := m.annotationFor(m.selectedSong); !annotation.Empty() {
+			songName = lipgloss.JoinVertical(lipgloss.Center, songName, renderAnnotation(annotation, 60))
+		}
+		artistName := m.info.Title(strings.Split(m.csvChosen, ".csv")[0])
+		if m.info.Artist != "" {
+			artistName += " · " + m.info.Artist
+		}
+		if m.snapshotID != "" {
+			artistName += " · snapshot " + m.snapshotID + " (read-only)"
+		}
+		artist := lipgloss.NewStyle().MarginBottom(1).Render(artistName)
 		prev := m.renderButton("<< prev", 0, m.controlState)
 		playPause := m.renderButton("play/pause", 1, m.controlState)
 		skip := m.renderButton("skip >>", 2, m.controlState)
@@ -277,17 +597,83 @@ func (m model) View() string {
 		if m.selectedLink == "Not Selected yet" {
 			link = m.selectedLink
 		} else {
-			link = "file from: https://" + strings.Split(strings.Split(m.selectedLink, "https://")[1], "/")[0]
+			link = "file from: " + linkHost(m.selectedLink)
+			if links := m.selectedSong.LinkList(); len(links) > 1 {
+				link += fmt.Sprintf(" (%d links)", len(links))
+			}
 		}
 		songProgression := lipgloss.NewStyle().MarginBottom(1).AlignHorizontal(lipgloss.Center).Render(m.songProgress.View())
 		link = lipgloss.NewStyle().MarginTop(1).Render(link)
 		if m.isDownloading {
 			downloadSpinner = lipgloss.NewStyle().MarginTop(1).Render(m.downloadSpinner.View() + "  Downloading")
+			if item, ok := m.playingItem(); ok && item.State == download.Active && item.Progress.Written > 0 {
+				bar := ""
+				if fraction := item.Progress.Fraction(); fraction >= 0 {
+					bar = m.downloadProgress.ViewAs(fraction) + "\n"
+				}
+				downloadSpinner = lipgloss.NewStyle().MarginTop(1).AlignHorizontal(lipgloss.Center).Render(bar + formatProgress(item.Progress))
+			}
+		}
+		if active, queued := countDownloads(m.downloadItems); active+queued > 0 {
+			downloadSpinner = lipgloss.JoinVertical(lipgloss.Center, downloadSpinner, styles.PanelLabel.MarginTop(1).Render(fmt.Sprintf("%d downloading, %d queued (o to view)", active, queued)))
+		}
+		if m.refreshing != "" {
+			downloadSpinner = lipgloss.JoinVertical(lipgloss.Center, downloadSpinner, lipgloss.NewStyle().MarginTop(1).Render(m.downloadSpinner.View()+"  Refreshing "+m.refreshing))
+		}
+		var status string
+		if m.statusMessage != "" {
+			status = lipgloss.NewStyle().MarginTop(1).Foreground(styles.ColorPrimary).Render(m.statusMessage)
+		}
+		player := lipgloss.JoinVertical(lipgloss.Center, songName, artist, songProgression, playButtons, link, downloadSpinner, status)
+		if m.linkPicker {
+			player = lipgloss.JoinVertical(lipgloss.Center, player, m.renderLinkPicker(60))
+		}
+		if m.csvTableState && (m.filtering || m.eraFilter != "") {
+			filter := m.filterInput.View()
+			if !m.filtering {
+				filter = fmt.Sprintf("filter: %s (%d of %d)", m.eraFilter, len(m.eraEntries), len(m.eraAll))
+			}
+			player = lipgloss.JoinVertical(lipgloss.Center, player, styles.PanelStyle.Width(60).MarginTop(2).Render(filter))
+		}
+		if len(m.tabs) > 1 {
+			player = lipgloss.JoinVertical(lipgloss.Center, m.renderTabBar(), player)
+		}
+		if !m.changes.Refreshed.IsZero() {
+			player = lipgloss.JoinVertical(lipgloss.Center, player, lipgloss.NewStyle().MarginTop(1).Foreground(styles.ColorAccent).Render(fmt.Sprintf("%d changes in the last refresh (n to view)", len(m.changes.Changes))))
+		}
+		if n := len(m.tracker.Warnings); n > 0 {
+			player = lipgloss.JoinVertical(lipgloss.Center, player, lipgloss.NewStyle().MarginTop(1).Foreground(styles.ColorPrimary).Render(fmt.Sprintf("⚠ %d parse warnings (w to view)", n)))
+		}
+		if m.searching {
+			player = m.renderSearch(70)
+		}
+		if m.showWarnings {
+			player = m.renderWarnings(80)
+		}
+		if m.showChanges {
+			player = m.renderChanges(80)
+		}
+		if m.showStats {
+			player = m.renderStats(statsWidth)
+		}
+		if m.annotating {
+			player = m.renderAnnotationForm(70)
+		}
+		if m.showDownloads {
+			player = m.renderDownloads(90)
+		}
+		if m.showCache {
+			player = m.renderCache(90)
+		}
+		if m.showDuplicates {
+			player = m.renderDuplicates(90)
 		}
-		player := lipgloss.JoinVertical(lipgloss.Center, songName, artist, songProgression, playButtons, link, downloadSpinner)
 		if m.csvTableState {
 			s += lipgloss.JoinHorizontal(lipgloss.Center, "\n"+styles.CsvTableBaseStyle.Height(m.termHeight-3).Render(m.erasTable.View()), lipgloss.NewStyle().Width(m.termWidth-m.tableWidth-9).Height(m.termHeight-1).AlignVertical(lipgloss.Center).AlignHorizontal(lipgloss.Center).Render("\n"+player))
 		} else {
+			if cursor := m.mainCSVTable.Cursor(); cursor >= 0 && cursor < len(m.tracker.Eras) {
+				player = lipgloss.JoinVertical(lipgloss.Center, player, renderEraPanel(m.tracker.Eras[cursor], 60))
+			}
 			s += lipgloss.JoinHorizontal(lipgloss.Center, "\n"+styles.CsvTableBaseStyle.Height(m.termHeight-3).Render(m.mainCSVTable.View()), lipgloss.NewStyle().Width(m.termWidth-m.tableWidth-20).Height(m.termHeight-1).AlignVertical(lipgloss.Center).AlignHorizontal(lipgloss.Center).Render("\n"+player))
 
 		}
@@ -309,10 +695,24 @@ func (m model) View() string {
 
 		case "sheetInput":
 			s = styles.Header.Width(m.termWidth).Render("tracker-tui")
-			s += styles.TextStyling.Width(m.termWidth).Render("\nEnter the link to the Google Sheet Tracker:\n\n", m.sheetInput.View()+"\n\n")
+			s += styles.TextStyling.Width(m.termWidth).Render("\nEnter the link to the Google Sheet Tracker, followed by any extra tab gids:\n\n", m.sheetInput.View()+"\n\n")
+			if m.isImporting {
+				s += styles.TextStyling.Render(m.downloadSpinner.View() + "  Importing")
+			} else if m.statusMessage != "" {
+				s += styles.TextStyling.Foreground(styles.ColorPrimary).Render(m.statusMessage)
+			}
 		case "list":
 			s = styles.Header.Width(m.termWidth).Render("tracker-tui")
 			s += styles.DocStyle.Render(m.csvList.View())
+			if m.refreshing != "" {
+				s += "\n" + styles.TextStyling.Render(m.downloadSpinner.View()+"  Refreshing "+m.refreshing)
+			}
+		case "library":
+			s = styles.Header.Width(m.termWidth).Render("tracker-tui")
+			s += lipgloss.Place(m.termWidth, m.termHeight-2, lipgloss.Center, lipgloss.Center, m.renderLibrary(min(m.termWidth-4, 100)))
+		case "editInfo":
+			s = styles.Header.Width(m.termWidth).Render("tracker-tui")
+			s += lipgloss.Place(m.termWidth, m.termHeight-2, lipgloss.Center, lipgloss.Center, m.renderInfoForm(70))
 		}
 	}
 	return s
@@ -336,6 +736,595 @@ func (m model) renderButton(label string, index int, parentState bool) string {
 		Render(label)
 }
 
+func renderEraPanel(era filemgmt.Era, width int) string {
+	lines := []string{styles.PanelTitle.Render(era.Name)}
+	if era.TimeFrame != "" {
+		lines = append(lines, era.TimeFrame)
+	}
+	lines = append(lines, "")
+
+	if len(era.Counts) > 0 {
+		for _, count := range era.Counts {
+			lines = append(lines, fmt.Sprintf("%s %d", styles.PanelLabel.Render(count.Label+":"), count.Count))
+		}
+	} else if era.Summary != "" {
+		lines = append(lines, era.Summary)
+	}
+	lines = append(lines, fmt.Sprintf("%s %d", styles.PanelLabel.Render("Entries in tracker:"), len(era.Entries)))
+
+	if era.Description != "" {
+		description := era.Description
+		if descLines := strings.Split(description, "\n"); len(descLines) > 8 {
+			description = strings.Join(descLines[:8], "\n") + "\n…"
+		}
+		lines = append(lines, "", description)
+	}
+
+	return styles.PanelStyle.Width(width).MarginTop(2).Render(strings.Join(lines, "\n"))
+}
+
+func renderCredits(title filemgmt.Title) string {
+	var parts []string
+	if badges := title.Badges(); badges != "" {
+		parts = append(parts, badges)
+	}
+	if len(title.Versions) > 0 {
+		parts = append(parts, strings.Join(title.Versions, ", "))
+	}
+	if len(title.Features) > 0 {
+		parts = append(parts, "feat. "+strings.Join(title.Features, ", "))
+	}
+	if len(title.Producers) > 0 {
+		parts = append(parts, "prod. "+strings.Join(title.Producers, ", "))
+	}
+	if len(title.AltTitles) > 0 {
+		parts = append(parts, "aka "+strings.Join(title.AltTitles, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// how many search results are visible at once
+const searchResultRows = 15
+
+// truncate shortens s to at most width terminal cells, ending it with "…"
+// when anything was cut. Wide characters count as two cells.
+func truncate(s string, width int) string {
+	return ansi.Truncate(s, width, "…")
+}
+
+func (m model) renderSearch(width int) string {
+	lines := []string{styles.PanelTitle.Render("Search " + strings.Split(m.csvChosen, ".csv")[0]), "", m.searchInput.View(), ""}
+
+	start := 0
+	if m.searchCursor >= searchResultRows {
+		start = m.searchCursor - searchResultRows + 1
+	}
+	for i := start; i < len(m.searchResults) && i < start+searchResultRows; i++ {
+		result := m.searchResults[i]
+		title := result.Entry.Title()
+		line := title.Badges() + " " + title.Base
+		if result.Matched != title.Base {
+			line += " (" + result.Matched + ")"
+		}
+		line = strings.TrimSpace(line)
+		era := styles.PanelLabel.Render(filemgmt.FormatTitle(result.Entry.Era))
+		if room := width - 6 - lipgloss.Width(era); room > 1 {
+			line = truncate(line, room)
+		}
+		if i == m.searchCursor {
+			line = styles.CsvTableSelectedStyle.Render(line)
+		}
+		lines = append(lines, line+"  "+era)
+	}
+
+	switch {
+	case m.searchInput.Value() == "":
+	case len(m.searchResults) == 0:
+		lines = append(lines, "no matches")
+	default:
+		lines = append(lines, "", styles.PanelLabel.Render(fmt.Sprintf("%d results · enter jump · esc close", len(m.searchResults))))
+	}
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderLibrary(width int) string {
+	title := fmt.Sprintf("Library · %d artists · %d entries", len(m.library.Artists), len(m.library.Entries))
+	switch m.libraryLevel {
+	case 1:
+		title = "Library › " + m.libraryArtist
+	case 2:
+		title = "Library › " + m.libraryArtist + " › " + m.libraryEra.Name
+	}
+	lines := []string{styles.PanelTitle.Render(title), ""}
+	if m.librarySearching {
+		lines = append(lines, m.libraryInput.View(), "")
+	}
+
+	rows := m.libraryRows()
+	visible := max(m.termHeight-14, 5)
+	start := 0
+	if m.libraryCursor >= visible {
+		start = m.libraryCursor - visible + 1
+	}
+	for i := start; i < len(rows) && i < start+visible; i++ {
+		line, where := rows[i][0], styles.PanelLabel.Render(rows[i][1])
+		if room := width - 6 - lipgloss.Width(where); room > 1 {
+			line = truncate(line, room)
+		}
+		if i == m.libraryCursor {
+			line = styles.CsvTableSelectedStyle.Render(line)
+		}
+		lines = append(lines, line+"  "+where)
+	}
+	if len(rows) == 0 {
+		if m.librarySearching && m.libraryInput.Value() != "" {
+			lines = append(lines, "no matches")
+		} else if !m.librarySearching {
+			lines = append(lines, "nothing indexed yet")
+		}
+	}
+
+	if m.statusMessage != "" {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(styles.ColorPrimary).Render(m.statusMessage))
+	}
+	help := "enter open · / search · R rebuild · esc back"
+	if m.librarySearching {
+		help = fmt.Sprintf("%d results · enter open · esc close search", len(m.libraryResults))
+	}
+	lines = append(lines, "", styles.PanelLabel.Render(help))
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+// libraryRows returns the label and location of each row the library view
+// currently lists.
+func (m model) libraryRows() [][2]string {
+	var rows [][2]string
+	switch {
+	case m.librarySearching:
+		for _, result := range m.libraryResults {
+			title := result.Entry.Title()
+			line := strings.TrimSpace(title.Badges() + " " + title.Base)
+			if result.Matched != title.Base {
+				line += " (" + result.Matched + ")"
+			}
+			rows = append(rows, [2]string{line, result.Artist + " / " + filemgmt.FormatTitle(result.Entry.Era)})
+		}
+	case m.libraryLevel == 0:
+		for _, artist := range m.library.Artists {
+			eras := m.library.ErasOf(artist.Name)
+			entries := 0
+			for _, era := range eras {
+				entries += era.Entries
+			}
+			rows = append(rows, [2]string{artist.Name, fmt.Sprintf("%d eras · %d entries", len(eras), entries)})
+		}
+	case m.libraryLevel == 1:
+		for _, era := range m.library.ErasOf(m.libraryArtist) {
+			where := fmt.Sprintf("%d entries", era.Entries)
+			if era.TimeFrame != "" {
+				where = era.TimeFrame + " · " + where
+			}
+			rows = append(rows, [2]string{era.Name, where})
+		}
+	case m.libraryLevel == 2:
+		for _, entry := range m.library.EntriesOf(m.libraryEra) {
+			title := entry.Entry.Title()
+			rows = append(rows, [2]string{strings.TrimSpace(title.Badges() + " " + title.Base), entry.Entry.Quality})
+		}
+	}
+	return rows
+}
+
+func (m model) renderTabBar() string {
+	var tabs []string
+	for i, tab := range m.tabs {
+		style := lipgloss.NewStyle().Padding(0, 1).Foreground(styles.ColorText)
+		if i == m.tabIndex {
+			style = style.Foreground(styles.ColorSelectedText).Background(styles.ColorAccent)
+		}
+		tabs = append(tabs, style.Render(tab.Tab))
+	}
+	bar := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	return lipgloss.JoinVertical(lipgloss.Center, bar, styles.PanelLabel.MarginBottom(2).Render("[ / ] switch tab"))
+}
+
+var changeLabels = map[filemgmt.ChangeKind]string{
+	filemgmt.ChangeAdded:   "new",
+	filemgmt.ChangeRemoved: "removed",
+	filemgmt.ChangeLeaked:  "leaked",
+	filemgmt.ChangeLinks:   "links",
+	filemgmt.ChangeQuality: "quality",
+	filemgmt.ChangeType:    "type",
+}
+
+func (m model) renderChanges(width int) string {
+	title := "What's new"
+	if !m.changes.Refreshed.IsZero() {
+		title += " since " + m.changes.Refreshed.Format("2006/01/02 15:04")
+	}
+	lines := []string{styles.PanelTitle.Render(title), ""}
+
+	if len(m.changes.Changes) == 0 {
+		lines = append(lines, "nothing changed in the last refresh")
+	}
+
+	rows := max(m.termHeight-12, 5)
+	start := 0
+	if m.changesCursor >= rows {
+		start = m.changesCursor - rows + 1
+	}
+	for i := start; i < len(m.changes.Changes) && i < start+rows; i++ {
+		change := m.changes.Changes[i]
+		line := fmt.Sprintf("%-8s %s", changeLabels[change.Kind], change.Entry.Title().Base)
+		if change.Old != "" || change.New != "" {
+			if change.Kind == filemgmt.ChangeQuality || change.Kind == filemgmt.ChangeType {
+				line += fmt.Sprintf(" (%s → %s)", change.Old, change.New)
+			}
+		}
+		where := filemgmt.FormatTitle(change.Entry.Era)
+		if len(m.tabs) > 1 {
+			where = change.Tab + " / " + where
+		}
+		where = styles.PanelLabel.Render(where)
+		if room := width - 6 - lipgloss.Width(where); room > 1 {
+			line = truncate(line, room)
+		}
+		if i == m.changesCursor {
+			line = styles.CsvTableSelectedStyle.Render(line)
+		}
+		lines = append(lines, line+"  "+where)
+	}
+	lines = append(lines, "", styles.PanelLabel.Render("enter jump · esc close"))
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+const statsWidth = 80
+
+func (m model) renderStats(width int) string {
+	title := "Stats"
+	if len(m.tabs) > 1 {
+		title += " · " + m.tracker.Tab
+	}
+
+	lines := m.statsLines(width)
+	rows := m.statsRows()
+	offset := min(m.statsOffset, max(len(lines)-rows, 0))
+	visible := lines[offset:min(offset+rows, len(lines))]
+
+	panel := []string{styles.PanelTitle.Render(title), ""}
+	panel = append(panel, visible...)
+	panel = append(panel, "", styles.PanelLabel.Render("↑/↓ scroll · esc close"))
+	return styles.PanelStyle.Width(width).Render(strings.Join(panel, "\n"))
+}
+
+func (m model) statsRows() int {
+	return max(m.termHeight-12, 5)
+}
+
+func (m model) statsLines(width int) []string {
+	stats := m.stats
+	var lines []string
+	lines = append(lines,
+		fmt.Sprintf("%d entries in %d eras", stats.Entries, len(stats.Eras)),
+		fmt.Sprintf("%d downloaded locally", stats.Downloaded),
+	)
+	if stats.KnownLengths > 0 {
+		lines = append(lines, fmt.Sprintf("total length %s, average %s (%d with a known length)",
+			formatLength(stats.TotalLength), formatLength(stats.AverageLength()), stats.KnownLengths))
+	}
+	sections := []struct {
+		title  string
+		counts []filemgmt.Count
+	}{
+		{"Entries per era", stats.Eras},
+		{"Type", stats.Types},
+		{"Quality", stats.Qualities},
+		{"Link hosts", stats.Hosts},
+	}
+	for _, section := range sections {
+		lines = append(lines, "", styles.PanelLabel.Render(section.title))
+		lines = append(lines, renderBars(section.counts, width-4)...)
+	}
+	return lines
+}
+
+// renderBars draws one horizontal bar per count, scaled to the largest one.
+func renderBars(counts []filemgmt.Count, width int) []string {
+	const labelWidth = 20
+	highest := 0
+	for _, count := range counts {
+		highest = max(highest, count.Count)
+	}
+	barWidth := width - labelWidth - 8
+	bar := lipgloss.NewStyle().Foreground(styles.ColorAccent)
+
+	var lines []string
+	for _, count := range counts {
+		label := truncate(count.Label, labelWidth)
+		// padded by cells rather than runes so wide labels line up
+		label += strings.Repeat(" ", labelWidth-lipgloss.Width(label))
+		length := 0
+		if highest > 0 {
+			length = max(count.Count*barWidth/highest, 1)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %d", label, bar.Render(strings.Repeat("█", length)), count.Count))
+	}
+	return lines
+}
+
+func formatLength(d time.Duration) string {
+	return filemgmt.Duration{Value: d, Known: true}.String()
+}
+
+func (m model) renderDuplicates(width int) string {
+	title := fmt.Sprintf("Possible duplicates (%d groups)", len(m.duplicates))
+	if m.duplicatesAll {
+		title += " across all trackers"
+	}
+	lines := []string{styles.PanelTitle.Render(title), ""}
+	if len(m.duplicates) == 0 {
+		lines = append(lines, "no duplicates found")
+	}
+
+	var rows []string
+	cursorRow := 0
+	for i, row := range m.duplicateRows {
+		if i == 0 || m.duplicateRows[i-1].group != row.group {
+			group := m.duplicates[row.group]
+			rows = append(rows, styles.PanelLabel.Render(fmt.Sprintf("── %d entries · same %s", len(group.Entries), strings.Join(group.Reasons, ", "))))
+		}
+		dup := row.entry
+		line := "  " + dup.Entry.Title().Base
+		if versions := dup.Entry.Title().Versions; len(versions) > 0 {
+			line += " [" + strings.Join(versions, ", ") + "]"
+		}
+		where := filemgmt.FormatTitle(dup.Entry.Era)
+		if len(m.tabs) > 1 || m.duplicatesAll {
+			where = dup.Tab + " / " + where
+		}
+		if m.duplicatesAll {
+			where = dup.Tracker + " / " + where
+		}
+		where = styles.PanelLabel.Render(where)
+		if room := width - 6 - lipgloss.Width(where); room > 1 {
+			line = truncate(line, room)
+		}
+		if i == m.duplicatesCursor {
+			line = styles.CsvTableSelectedStyle.Render(line)
+			cursorRow = len(rows)
+		}
+		rows = append(rows, line+"  "+where)
+	}
+
+	visible := max(m.termHeight-12, 5)
+	start := 0
+	if cursorRow >= visible {
+		start = cursorRow - visible + 1
+	}
+	lines = append(lines, rows[start:min(start+visible, len(rows))]...)
+	scope := "a all trackers"
+	if m.duplicatesAll {
+		scope = "a this tracker"
+	}
+	lines = append(lines, "", styles.PanelLabel.Render("enter jump · "+scope+" · esc close"))
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+var linkStatusLabels = map[filemgmt.LinkStatus]string{
+	filemgmt.LinkUnchecked: "unchecked",
+	filemgmt.LinkVerified:  "✓ verified",
+	filemgmt.LinkBroken:    "✗ broken",
+}
+
+func renderAnnotation(annotation filemgmt.Annotation, width int) string {
+	var lines []string
+	if annotation.Link != filemgmt.LinkUnchecked {
+		lines = append(lines, styles.PanelLabel.Render("link: ")+linkStatusLabels[annotation.Link])
+	}
+	if len(annotation.Tags) > 0 {
+		lines = append(lines, styles.PanelLabel.Render("tags: ")+"#"+strings.Join(annotation.Tags, " #"))
+	}
+	if annotation.Note != "" {
+		lines = append(lines, styles.PanelLabel.Render("note: ")+annotation.Note)
+	}
+	return styles.PanelStyle.Width(width).MarginBottom(1).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderAnnotationForm(width int) string {
+	lines := []string{styles.PanelTitle.Render("Annotate " + m.annotatedEntry.Title().Base), ""}
+	for _, input := range m.annotationInputs {
+		lines = append(lines, input.View())
+	}
+	link := "link: " + linkStatusLabels[m.annotationLink]
+	if m.annotationFocus == len(m.annotationInputs) {
+		link = styles.CsvTableSelectedStyle.Render(link + " ←/→")
+	}
+	lines = append(lines, link, "", styles.PanelLabel.Render("tab next field · enter save · esc cancel"))
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) playingItem() (download.Item, bool) {
+	for _, item := range m.downloadItems {
+		if item.ID == m.playingDownload {
+			return item, true
+		}
+	}
+	return download.Item{}, false
+}
+
+// formatProgress reads like "1.2 MB / 4.5 MB · 512 kB/s · 6s left".
+func formatProgress(p download.Progress) string {
+	text := humanize.Bytes(p.Written)
+	if p.Size > 0 {
+		text += " / " + humanize.Bytes(p.Size)
+	}
+	if p.Speed > 0 {
+		text += " · " + humanize.Bytes(uint64(p.Speed)) + "/s"
+	}
+	if p.ETA > 0 {
+		text += " · " + p.ETA.Round(time.Second).String() + " left"
+	}
+	return text
+}
+
+func countDownloads(items []download.Item) (active int, queued int) {
+	for _, item := range items {
+		switch item.State {
+		case download.Active:
+			active++
+		case download.Queued:
+			queued++
+		}
+	}
+	return active, queued
+}
+
+var priorityLabels = map[download.Priority]string{
+	download.PriorityLow:    "low",
+	download.PriorityNormal: "",
+	download.PriorityHigh:   "high",
+}
+
+func (m model) renderDownloads(width int) string {
+	items := m.downloadRows()
+	lines := []string{styles.PanelTitle.Render(fmt.Sprintf("Downloads (%d)", len(items))), ""}
+	if len(items) == 0 {
+		lines = append(lines, "nothing downloaded yet, q queues the selected song")
+	}
+
+	rows := max(m.termHeight-12, 5)
+	start := 0
+	if m.downloadsCursor >= rows {
+		start = m.downloadsCursor - rows + 1
+	}
+	for i := start; i < len(items) && i < start+rows; i++ {
+		item := items[i]
+		name := item.File
+		if name == "" {
+			name = item.Fallback
+		}
+		status := item.State.String()
+		switch {
+		case item.State == download.Active:
+			status += " " + formatProgress(item.Progress)
+		case item.State == download.Failed && item.Err != nil:
+			status += ": " + item.Err.Error()
+			var downloadErr *download.DownloadError
+			if errors.As(item.Err, &downloadErr) && downloadErr.Temporary() {
+				status += ", r to retry"
+			}
+		}
+		if label := priorityLabels[item.Priority]; label != "" && !item.State.Finished() {
+			status += " · " + label
+		}
+		status = styles.PanelLabel.Render(status)
+		line := name
+		if room := width - 6 - lipgloss.Width(status); room > 1 {
+			line = truncate(line, room)
+		}
+		if i == m.downloadsCursor {
+			line = styles.CsvTableSelectedStyle.Render(line)
+		}
+		lines = append(lines, line+"  "+status)
+	}
+	lines = append(lines, "", styles.PanelLabel.Render("p pause/resume · x cancel · r retry · +/- priority · del remove · enter play · esc close"))
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderCache(width int) string {
+	title := fmt.Sprintf("Song cache (%d songs · %s", len(m.cacheRows), humanize.Bytes(uint64(m.cacheSize)))
+	if limit := filemgmt.CurrentSettings.CacheMaxMB; limit > 0 {
+		title += " of " + humanize.Bytes(uint64(limit)<<20)
+	}
+	lines := []string{styles.PanelTitle.Render(title + ")"), ""}
+	if len(m.cacheRows) == 0 {
+		lines = append(lines, "nothing cached yet, songs are cached as they are played")
+	}
+
+	rows := max(m.termHeight-12, 5)
+	start := 0
+	if m.cacheCursor >= rows {
+		start = m.cacheCursor - rows + 1
+	}
+	for i := start; i < len(m.cacheRows) && i < start+rows; i++ {
+		song := m.cacheRows[i]
+		if i == start || song.Tracker != m.cacheRows[i-1].Tracker {
+			tracker := m.cacheTrackers[song.Tracker]
+			if song.Tracker == "" {
+				tracker = "(no tracker)"
+			}
+			lines = append(lines, styles.PanelTitle.Render(tracker))
+		}
+		status := humanize.Bytes(uint64(song.Size))
+		if song.Era != "" {
+			status = song.Era + " · " + status
+		}
+		if song.Pinned {
+			status += " · pinned"
+		}
+		status = styles.PanelLabel.Render(status)
+		line := song.Title
+		if line == "" {
+			line = song.File
+		}
+		if room := width - 6 - lipgloss.Width(status); room > 1 {
+			line = truncate(line, room)
+		}
+		if i == m.cacheCursor {
+			line = styles.CsvTableSelectedStyle.Render(line)
+		}
+		lines = append(lines, line+"  "+status)
+	}
+	lines = append(lines, "", styles.PanelLabel.Render("enter play · p pin/unpin · del delete · E clear era · esc close"))
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderWarnings(width int) string {
+	lines := []string{styles.PanelTitle.Render(fmt.Sprintf("Parse warnings (%d)", len(m.tracker.Warnings))), ""}
+	rows := max(m.termHeight-12, 5)
+	for i := m.warningsOffset; i < len(m.tracker.Warnings) && i < m.warningsOffset+rows; i++ {
+		lines = append(lines, truncate(m.tracker.Warnings[i].String(), width-4))
+	}
+	lines = append(lines, "", styles.PanelLabel.Render("↑/↓ scroll · esc close"))
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderInfoForm(width int) string {
+	lines := []string{styles.PanelTitle.Render("Edit " + m.editingInfo), ""}
+	for _, input := range m.infoInputs {
+		lines = append(lines, input.View())
+	}
+	if m.info.Source != "" {
+		lines = append(lines, "", styles.PanelLabel.Render("source: ")+m.info.Source)
+	}
+	if !m.info.Imported.IsZero() {
+		lines = append(lines, styles.PanelLabel.Render("imported: ")+m.info.Imported.Format("2006/01/02 15:04"))
+	}
+	lines = append(lines, "", styles.PanelLabel.Render("tab next field · enter save · esc cancel"))
+	return styles.PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderLinkPicker(width int) string {
+	lines := []string{styles.PanelTitle.Render("Choose a link"), ""}
+	for i, link := range m.linkPickerLinks {
+		line := truncate(fmt.Sprintf("%d. %s", i+1, link), width-4)
+		if i == m.linkPickerCursor {
+			line = styles.CsvTableSelectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", styles.PanelLabel.Render("enter play · esc close"))
+	return styles.PanelStyle.Width(width).MarginTop(2).Render(strings.Join(lines, "\n"))
+}
+
+func linkHost(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return link
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
 func tick() tea.Cmd {
 	return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
 		return tickMsg(t)
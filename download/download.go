@@ -2,72 +2,96 @@ package download
 
 // CODE FROM https://gist.github.com/cnu/026744b1e86c6d9e22313d06cba4c2e9
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"net/http"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
+// Progress describes a download in flight. Size is 0 when the server didn't
+// send a Content-Length.
+type Progress struct {
+	Written uint64
+	Size    uint64
+	// Speed is the average rate since the download started, in bytes per
+	// second.
+	Speed float64
+	ETA   time.Duration
+}
+
+// Fraction returns how much of the download is done, or -1 when the size is
+// unknown.
+func (p Progress) Fraction() float64 {
+	if p.Size == 0 {
+		return -1
+	}
+	return min(float64(p.Written)/float64(p.Size), 1)
+}
+
 type WriteCounter struct {
 	Total uint64
+	// Size is the expected length, 0 when unknown.
+	Size uint64
+	// OnProgress, when set, is called after every write.
+	OnProgress func(Progress)
+	started    time.Time
+	// bytes that were already there when a download was resumed
+	resumedFrom uint64
 }
 
 // Write implements io.Writer.
 func (wc *WriteCounter) Write(p []byte) (int, error) {
+	if wc.started.IsZero() {
+		wc.started = time.Now()
+	}
 	n := len(p)
 	wc.Total += uint64(n)
+	if wc.OnProgress != nil {
+		wc.OnProgress(wc.Progress())
+	}
 	return n, nil
 }
 
-func ConvertSheetURL(sheetURL string) (string, error) {
-	re := regexp.MustCompile(`https://docs\.google\.com/spreadsheets/d/([a-zA-Z0-9-_]+)/edit\?gid=([0-9]+)`)
-
-	// Match the URL
-	matches := re.FindStringSubmatch(sheetURL)
-	if len(matches) < 3 {
-		return "", fmt.Errorf("invalid Google Sheets URL")
+func (wc *WriteCounter) Progress() Progress {
+	progress := Progress{Written: wc.Total, Size: wc.Size}
+	if elapsed := time.Since(wc.started).Seconds(); elapsed > 0 && !wc.started.IsZero() {
+		progress.Speed = float64(wc.Total-wc.resumedFrom) / elapsed
 	}
+	if progress.Speed > 0 && wc.Size > wc.Total {
+		progress.ETA = time.Duration(float64(wc.Size-wc.Total) / progress.Speed * float64(time.Second))
+	}
+	return progress
+}
 
-	// Extract the spreadsheet ID and GID
-	spreadsheetID := matches[1]
-	sheetGID := matches[2]
+func ConvertSheetURL(sheetURL string) (string, error) {
+	ref, err := ParseSheetURL(sheetURL)
+	if err != nil {
+		return "", err
+	}
+	if len(ref.GIDs) == 0 {
+		ref.GIDs = []string{"0"}
+	}
 
 	// Construct the CSV export URL
-	downloadURL := fmt.Sprintf(
-		"https://docs.google.com/spreadsheets/d/%s/export?format=csv&gid=%s",
-		spreadsheetID,
-		sheetGID,
-	)
-
-	return downloadURL, nil
+	return SheetExportURL(ref.ID, ref.GIDs[0]), nil
 }
 
+// ConvertLink returns the direct download URL for a tracker link, see
+// Resolve.
 func ConvertLink(input string) (string, error) {
-	parsedURL, err := url.Parse(input)
+	resolved, err := Resolve(context.Background(), input)
 	if err != nil {
-		return "", fmt.Errorf("invalid URL: %w", err)
-	}
-
-	host := strings.ToLower(parsedURL.Host)
-	if strings.Contains(host, "youtube.com") || strings.Contains(host, "youtu.be") {
-		// Just return the original input for YouTube links
-		return input, nil
-	}
-
-	parts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
-	if len(parts) != 2 || parts[0] != "f" {
-		return "", fmt.Errorf("unexpected URL format")
+		return "", err
 	}
-
-	downloadID := parts[1]
-	newURL := "https://api.pillowcase.su/api/download/" + downloadID
-	return newURL, nil
+	return resolved.URL, nil
 }
 
 func DownloadFile(url string, fallbackFilename string, csvOrAudio bool) (string, error) {
@@ -88,18 +112,122 @@ func DownloadFile(url string, fallbackFilename string, csvOrAudio bool) (string,
 		downloadDir = filepath.Join(homeDir, "Documents", "tracker-tui", "songs")
 
 	}
-	err = os.MkdirAll(downloadDir, os.ModePerm)
+	return DownloadFileTo(url, downloadDir, fallbackFilename)
+}
+
+// DownloadFileTo downloads into the given directory, naming the file from
+// the Content-Disposition header when there is one.
+func DownloadFileTo(url string, downloadDir string, fallbackFilename string) (string, error) {
+	return downloadFileTo(context.Background(), request{url: url, dir: downloadDir, fallback: fallbackFilename})
+}
+
+// HTTPClient is used for every download, tests can point it elsewhere.
+var HTTPClient = http.DefaultClient
+
+// Verifier checks a finished download before it is moved into place. It
+// returns the name to save the file under, or an error to reject it.
+type Verifier func(tmpPath string, filename string) (string, error)
+
+// request is one file to download into dir. The file is named from the
+// Content-Disposition header, then suggested, then fallback.
+type request struct {
+	url       string
+	header    http.Header
+	dir       string
+	suggested string
+	fallback  string
+	// onProgress is called after every chunk when set
+	onProgress func(Progress)
+	// verify checks the finished file when set
+	verify Verifier
+	// client replaces HTTPClient when set
+	client *http.Client
+}
+
+// downloadFileTo is DownloadFileTo with cancellation, progress and
+// verification. Temporary failures are retried, see withRetries, and errors
+// come back as *DownloadError.
+func downloadFileTo(ctx context.Context, r request) (string, error) {
+	var filename string
+	err := withRetries(ctx, func() error {
+		var err error
+		filename, err = downloadOnce(ctx, r, false)
+		return classifyError(r.url, err)
+	})
+	return filename, err
+}
+
+// downloadOnce makes a single attempt. An interrupted download keeps its
+// .tmp file and is resumed with a Range request next time, or restarted when
+// the server can't resume it. A download is only restarted once, restarted
+// is set on that second try.
+func downloadOnce(ctx context.Context, r request, restarted bool) (string, error) {
+	url, downloadDir, fallbackFilename := r.url, r.dir, r.fallback
+	err := os.MkdirAll(downloadDir, os.ModePerm)
 	if err != nil {
 		return "", err
 	}
 
 	// Request the file
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+
+	part, resuming := readPartial(downloadDir, url)
+	var offset uint64
+	if resuming {
+		info, err := os.Stat(filepath.Join(downloadDir, part.File+".tmp"))
+		if err == nil && info.Size() > 0 && part.validator() != "" {
+			offset = uint64(info.Size())
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", part.validator())
+		}
+	}
+
+	client := HTTPClient
+	if r.client != nil {
+		client = r.client
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	counter := &WriteCounter{OnProgress: r.onProgress}
+	if offset > 0 && resp.StatusCode == http.StatusPartialContent {
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if ok && start == offset {
+			out, err := os.OpenFile(filepath.Join(downloadDir, part.File+".tmp"), os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				return part.File, err
+			}
+			defer out.Close()
+			counter.Total, counter.resumedFrom, counter.Size = offset, offset, size
+			return finishDownload(downloadDir, part, out, resp.Body, counter, r.verify)
+		}
+	}
+	if offset > 0 && (resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable) {
+		// the server sent something other than the rest of our file
+		removePartial(downloadDir, part)
+		resp.Body.Close()
+		if restarted {
+			return "", &DownloadError{Kind: ErrOther, URL: url, Status: resp.StatusCode, Err: errors.New("download can't be restarted")}
+		}
+		return downloadOnce(ctx, r, true)
+	}
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+	if offset > 0 {
+		// the server ignored the range, start over
+		removePartial(downloadDir, part)
+	}
+
 	// Try to get filename from the "Content-Disposition" header
 	contentDisposition := resp.Header.Get("Content-Disposition")
 	filename := ""
@@ -114,49 +242,83 @@ func DownloadFile(url string, fallbackFilename string, csvOrAudio bool) (string,
 	}
 
 	// If no filename found, fallback
+	if filename == "" {
+		filename = r.suggested
+	}
 	if filename == "" {
 		filename = fallbackFilename
 	}
 
 	filename = sanitizeFilename(filename)
 
-	filepath := filepath.Join(downloadDir, filename)
-
 	// Create temp file
-	out, err := os.Create(filepath + ".tmp")
+	out, err := os.Create(filepath.Join(downloadDir, filename+".tmp"))
 	if err != nil {
 		return filename, err
 	}
 	defer out.Close()
 
-	// Write data
-	counter := &WriteCounter{}
-	_, err = io.Copy(out, io.TeeReader(resp.Body, counter))
-	if err != nil {
+	part = partial{
+		URL:          url,
+		File:         filename,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.ContentLength > 0 {
+		part.Size = uint64(resp.ContentLength)
+		counter.Size = part.Size
+	}
+	if err := writePartial(downloadDir, part); err != nil {
 		return filename, err
 	}
+	return finishDownload(downloadDir, part, out, resp.Body, counter, r.verify)
+}
+
+// finishDownload writes the rest of the body and moves the file into place,
+// returning the name it was saved under.
+func finishDownload(downloadDir string, part partial, out *os.File, body io.Reader, counter *WriteCounter, verify Verifier) (string, error) {
+	// Write data
+	if _, err := io.Copy(out, io.TeeReader(body, counter)); err != nil {
+		return part.File, err
+	}
+	if err := out.Close(); err != nil {
+		return part.File, err
+	}
+
+	tmpPath := filepath.Join(downloadDir, part.File+".tmp")
+	filename := part.File
+	if verify != nil {
+		var err error
+		filename, err = verify(tmpPath, part.File)
+		if err != nil {
+			removePartial(downloadDir, part)
+			return part.File, &DownloadError{Kind: ErrBadContent, URL: part.URL, Err: err}
+		}
+	}
 
 	// Rename file
-	err = os.Rename(filepath+".tmp", filepath)
-	if err != nil {
+	if err := os.Rename(tmpPath, filepath.Join(downloadDir, filename)); err != nil {
 		return filename, err
 	}
-
+	os.Remove(partialPath(downloadDir, part.URL))
 	return filename, nil
 }
 
 func downloadFromYT(url string, fallbackFilename string) (string, error) {
 	homeDir, _ := os.UserHomeDir()
 	downloadDir := filepath.Join(homeDir, "Documents", "tracker-tui", "songs")
+	return downloadFromYTTo(context.Background(), url, downloadDir, fallbackFilename)
+}
 
+func downloadFromYTTo(ctx context.Context, url string, downloadDir string, fallbackFilename string) (string, error) {
 	err := os.MkdirAll(downloadDir, os.ModePerm)
 	if err != nil {
 		return "", err
 	}
 
 	// yt-dlp -o "~/Documents/tracker-tui/songs/%(title)s.%(ext)s" -t mp3 https://youtu.be/sA3TpJzsFHc
-	outputTemplate := fmt.Sprintf("%s/Documents/tracker-tui/songs/%s.%%(ext)s", homeDir, fallbackFilename)
-	cmd := exec.Command(
+	outputTemplate := filepath.Join(downloadDir, fallbackFilename+".%(ext)s")
+	cmd := exec.CommandContext(ctx,
 		"yt-dlp",
 		"-x",
 		"--audio-format", "mp3",
@@ -165,7 +327,10 @@ func downloadFromYT(url string, fallbackFilename string) (string, error) {
 	)
 	// Run the command
 	if err := cmd.Run(); err != nil {
-		return "", nil
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", &DownloadError{URL: url, Err: fmt.Errorf("yt-dlp: %w", err)}
 	}
 	return fallbackFilename + ".mp3", nil
 }
@@ -0,0 +1,184 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+type ErrorKind int
+
+const (
+	ErrOther ErrorKind = iota
+	ErrNotFound
+	ErrRateLimited
+	ErrHostDown
+	ErrServer
+	ErrInterrupted
+	ErrBadContent
+)
+
+// DownloadError says why a download failed in terms the player can show.
+type DownloadError struct {
+	Kind   ErrorKind
+	URL    string
+	Status int
+	// RetryAfter is how long a rate limited host asked us to wait.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *DownloadError) Error() string {
+	host := e.URL
+	if parsed, err := url.Parse(e.URL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	switch e.Kind {
+	case ErrNotFound:
+		return fmt.Sprintf("file not found on %s (%d)", host, e.Status)
+	case ErrRateLimited:
+		if e.RetryAfter > 0 {
+			return fmt.Sprintf("rate limited by %s, try again in %s", host, e.RetryAfter.Round(time.Second))
+		}
+		return fmt.Sprintf("rate limited by %s", host)
+	case ErrHostDown:
+		return fmt.Sprintf("%s is not responding", host)
+	case ErrServer:
+		return fmt.Sprintf("%s had a server error (%d)", host, e.Status)
+	case ErrInterrupted:
+		return fmt.Sprintf("download from %s was interrupted", host)
+	case ErrBadContent:
+		if e.Err != nil {
+			return fmt.Sprintf("%s sent a bad file: %v", host, e.Err)
+		}
+		return fmt.Sprintf("%s sent a web page instead of a file", host)
+	}
+	if e.Status != 0 {
+		return fmt.Sprintf("%s refused the download (%d)", host, e.Status)
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return "download failed"
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether trying again later might work.
+func (e *DownloadError) Temporary() bool {
+	switch e.Kind {
+	case ErrRateLimited, ErrHostDown, ErrServer, ErrInterrupted:
+		return true
+	}
+	return false
+}
+
+// checkResponse rejects error statuses and pages served in place of a file.
+func checkResponse(resp *http.Response) error {
+	e := &DownloadError{URL: resp.Request.URL.String(), Status: resp.StatusCode}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		e.Kind = ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+		e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != "":
+		e.Kind = ErrRateLimited
+		e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case resp.StatusCode >= 500:
+		e.Kind = ErrServer
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		e.Kind = ErrOther
+	default:
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if mediaType != "text/html" && mediaType != "application/json" {
+			return nil
+		}
+		e.Kind = ErrBadContent
+	}
+	return e
+}
+
+// classifyError turns transport errors into DownloadErrors, cancellation is
+// passed through untouched.
+func classifyError(rawURL string, err error) error {
+	var downloadErr *DownloadError
+	if err == nil || errors.As(err, &downloadErr) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	// everything not recognised below, bad certificates and malformed or
+	// unsupported URLs among them, is permanent
+	e := &DownloadError{Kind: ErrOther, URL: rawURL, Err: err}
+	var netErr net.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET):
+		e.Kind = ErrInterrupted
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		// the host doesn't exist, waiting won't change that
+	case errors.As(err, &netErr) && netErr.Timeout():
+		e.Kind = ErrHostDown
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		e.Kind = ErrHostDown
+	}
+	return e
+}
+
+// parseRetryAfter reads both forms of Retry-After, seconds or a date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+var (
+	// MaxAttempts is how many times a download is tried before giving up.
+	MaxAttempts = 4
+	// RetryBaseDelay is the first backoff, doubled after every attempt.
+	RetryBaseDelay = time.Second
+	// RetryMaxDelay caps both the backoff and a host's Retry-After.
+	RetryMaxDelay = time.Minute
+)
+
+// withRetries runs attempt until it succeeds, fails for good or ctx is done,
+// backing off exponentially between temporary failures.
+func withRetries(ctx context.Context, attempt func() error) error {
+	delay := RetryBaseDelay
+	for i := 1; ; i++ {
+		err := attempt()
+		var downloadErr *DownloadError
+		if err == nil || i >= MaxAttempts || !errors.As(err, &downloadErr) || !downloadErr.Temporary() {
+			return err
+		}
+
+		wait := max(delay, downloadErr.RetryAfter)
+		wait = min(wait, RetryMaxDelay)
+		delay *= 2
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fastRetries shrinks the backoff so retry tests don't sleep.
+func fastRetries(t *testing.T) {
+	t.Helper()
+	base, maxDelay := RetryBaseDelay, RetryMaxDelay
+	RetryBaseDelay, RetryMaxDelay = time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { RetryBaseDelay, RetryMaxDelay = base, maxDelay })
+}
+
+// serveStatus answers every request with status, counting them.
+func serveStatus(status int, header http.Header, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+	}))
+}
+
+func TestRetries(t *testing.T) {
+	fastRetries(t)
+	tests := []struct {
+		status   int
+		header   http.Header
+		kind     ErrorKind
+		attempts int
+	}{
+		{http.StatusInternalServerError, nil, ErrServer, MaxAttempts},
+		{http.StatusBadGateway, nil, ErrServer, MaxAttempts},
+		{http.StatusTooManyRequests, nil, ErrRateLimited, MaxAttempts},
+		{http.StatusServiceUnavailable, http.Header{"Retry-After": {"0"}}, ErrRateLimited, MaxAttempts},
+		{http.StatusNotFound, nil, ErrNotFound, 1},
+		{http.StatusGone, nil, ErrNotFound, 1},
+		{http.StatusForbidden, nil, ErrOther, 1},
+		{http.StatusUnauthorized, nil, ErrOther, 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.status), func(t *testing.T) {
+			var requests int
+			srv := serveStatus(tt.status, tt.header, &requests)
+			defer srv.Close()
+
+			_, err := downloadFileTo(context.Background(), request{url: srv.URL, dir: t.TempDir()})
+			var dlErr *DownloadError
+			if !errors.As(err, &dlErr) {
+				t.Fatalf("got %v, want a DownloadError", err)
+			}
+			if dlErr.Kind != tt.kind || dlErr.Status != tt.status {
+				t.Errorf("got kind %d status %d, want kind %d status %d", dlErr.Kind, dlErr.Status, tt.kind, tt.status)
+			}
+			if requests != tt.attempts {
+				t.Errorf("made %d requests, want %d", requests, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryRecovers(t *testing.T) {
+	fastRetries(t)
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="song.mp3"`)
+		w.Write(song)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	filename, err := downloadFileTo(context.Background(), request{url: srv.URL, dir: dir})
+	checkDownload(t, dir, filename, err)
+	if requests != 3 {
+		t.Fatalf("made %d requests, want 3", requests)
+	}
+}
+
+func TestRetryAfterReset(t *testing.T) {
+	fastRetries(t)
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			// promise the whole song, send part of it and reset the connection
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\nContent-Disposition: attachment; filename=\"song.mp3\"\r\n\r\n", len(song))
+			buf.Write(song[:100])
+			buf.Flush()
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+			return
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="song.mp3"`)
+		w.Write(song)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	filename, err := downloadFileTo(context.Background(), request{url: srv.URL, dir: dir})
+	checkDownload(t, dir, filename, err)
+	if requests != 2 {
+		t.Fatalf("made %d requests, want a retry after the reset", requests)
+	}
+}
+
+func TestNoRetryWhenCanceled(t *testing.T) {
+	fastRetries(t)
+	var requests int
+	srv := serveStatus(http.StatusInternalServerError, nil, &requests)
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := downloadFileTo(ctx, request{url: srv.URL, dir: t.TempDir()})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if requests != 0 {
+		t.Fatalf("made %d requests after cancelling", requests)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	// a port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedURL := "http://" + listener.Addr().String()
+	listener.Close()
+	_, refused := http.Get(closedURL)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	_, timeout := (&http.Client{Timeout: 20 * time.Millisecond}).Get(slow.URL)
+
+	_, badScheme := http.Get("ftp://example.com/song.mp3")
+
+	tests := []struct {
+		name      string
+		err       error
+		kind      ErrorKind
+		temporary bool
+	}{
+		{"refused", refused, ErrHostDown, true},
+		{"timeout", timeout, ErrHostDown, true},
+		{"reset", fmt.Errorf("read: %w", syscall.ECONNRESET), ErrInterrupted, true},
+		{"cut short", fmt.Errorf("copy: %w", io.ErrUnexpectedEOF), ErrInterrupted, true},
+		{"no such host", &net.DNSError{Err: "no such host", Name: "nope.invalid", IsNotFound: true}, ErrOther, false},
+		{"bad scheme", badScheme, ErrOther, false},
+		{"other", errors.New("disk full"), ErrOther, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil {
+				t.Fatal("expected the request to fail")
+			}
+			var dlErr *DownloadError
+			if !errors.As(classifyError("http://example.com/song.mp3", tt.err), &dlErr) {
+				t.Fatal("not classified")
+			}
+			if dlErr.Kind != tt.kind || dlErr.Temporary() != tt.temporary {
+				t.Errorf("got kind %d temporary %v, want kind %d temporary %v", dlErr.Kind, dlErr.Temporary(), tt.kind, tt.temporary)
+			}
+		})
+	}
+
+	if err := classifyError("", context.Canceled); err != context.Canceled {
+		t.Errorf("cancellation came back as %v", err)
+	}
+}
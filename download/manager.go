@@ -0,0 +1,363 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	Queued State = iota
+	Active
+	Paused
+	Done
+	Failed
+	Canceled
+)
+
+var stateNames = []string{"queued", "downloading", "paused", "done", "failed", "canceled"}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return ""
+	}
+	return stateNames[s]
+}
+
+// Finished reports whether the item will not be picked up again on its own.
+func (s State) Finished() bool {
+	return s == Done || s == Failed || s == Canceled
+}
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// Item is a snapshot of one download in the manager's queue.
+type Item struct {
+	ID       int
+	URL      string
+	Dir      string
+	Fallback string
+	Priority Priority
+	State    State
+	// File is the name the download was saved under, once known.
+	File     string
+	Progress Progress
+	Err      error
+	Added    time.Time
+}
+
+type job struct {
+	Item
+	cancel context.CancelFunc
+	// state the item moves to once its worker notices the cancellation,
+	// Active while nobody asked it to stop
+	stopAs State
+	// fetched is the resolved URL, unfinished downloads are kept under it
+	fetched string
+}
+
+// how often progress is sent while an item is downloading
+const progressInterval = 200 * time.Millisecond
+
+// Manager downloads queued items on a fixed pool of workers, highest
+// priority first. The changes workers make to items (progress, done, failed)
+// are sent on Updates; changes made through the Manager's methods are not,
+// callers read Items afterwards instead.
+type Manager struct {
+	mu      sync.Mutex
+	jobs    []*job
+	nextID  int
+	wake    chan struct{}
+	updates chan Item
+	workers int
+	verify  Verifier
+	// resolvers replaces the package's Resolvers when set
+	resolvers Registry
+}
+
+// NewManager creates a manager with the given number of workers. Every
+// finished download is checked with verify when it is set.
+func NewManager(workers int, verify Verifier) *Manager {
+	return &Manager{
+		wake:    make(chan struct{}, 1),
+		updates: make(chan Item, 64),
+		workers: max(workers, 1),
+		verify:  verify,
+	}
+}
+
+// Start launches the workers, they stop when ctx is done.
+func (m *Manager) Start(ctx context.Context) {
+	for i := 0; i < m.workers; i++ {
+		go m.work(ctx)
+	}
+}
+
+func (m *Manager) Updates() <-chan Item {
+	return m.updates
+}
+
+// Add queues a download into dir and returns its id.
+func (m *Manager) Add(url string, dir string, fallback string, priority Priority) int {
+	m.mu.Lock()
+	m.nextID++
+	j := &job{Item: Item{
+		ID:       m.nextID,
+		URL:      url,
+		Dir:      dir,
+		Fallback: fallback,
+		Priority: priority,
+		State:    Queued,
+		Added:    time.Now(),
+	}}
+	m.jobs = append(m.jobs, j)
+	m.mu.Unlock()
+
+	m.signal()
+	return j.ID
+}
+
+// Items returns every item, in the order they were added.
+func (m *Manager) Items() []Item {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := make([]Item, len(m.jobs))
+	for i, j := range m.jobs {
+		items[i] = j.Item
+	}
+	return items
+}
+
+func (m *Manager) Get(id int) (Item, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j := m.find(id); j != nil {
+		return j.Item, true
+	}
+	return Item{}, false
+}
+
+func (m *Manager) Cancel(id int) {
+	m.stop(id, Canceled)
+}
+
+// Pause stops an item without forgetting it, Resume queues it again.
+func (m *Manager) Pause(id int) {
+	m.stop(id, Paused)
+}
+
+func (m *Manager) stop(id int, state State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j := m.find(id)
+	if j == nil || j.State.Finished() || j.State == state {
+		return
+	}
+	if j.State == Active {
+		// the worker reports the new state once the download has stopped
+		j.stopAs = state
+		j.cancel()
+		return
+	}
+	j.State = state
+	if state == Canceled {
+		m.discard(j)
+	}
+}
+
+// discard removes the files an unfinished download left behind.
+func (m *Manager) discard(j *job) {
+	if j.fetched != "" {
+		discardPartial(j.Dir, j.fetched)
+	}
+}
+
+// Resume queues a paused, failed or canceled item again.
+func (m *Manager) Resume(id int) {
+	m.mu.Lock()
+	j := m.find(id)
+	if j == nil || j.State == Queued || j.State == Done || (j.State == Active && j.stopAs == Active) {
+		m.mu.Unlock()
+		return
+	}
+	if j.State == Active {
+		// a pause or cancel is still pending, the worker queues the item again
+		// once it has stopped instead
+		j.stopAs = Queued
+		m.mu.Unlock()
+		return
+	}
+	j.State = Queued
+	j.Err = nil
+	j.Progress = Progress{}
+	m.mu.Unlock()
+
+	m.signal()
+}
+
+func (m *Manager) SetPriority(id int, priority Priority) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j := m.find(id); j != nil {
+		j.Priority = min(max(priority, PriorityLow), PriorityHigh)
+	}
+}
+
+// Remove drops a finished item from the list.
+func (m *Manager) Remove(id int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, j := range m.jobs {
+		if j.ID == id && j.State.Finished() {
+			m.discard(j)
+			m.jobs = append(m.jobs[:i], m.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m *Manager) find(id int) *job {
+	for _, j := range m.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next claims the queued item with the highest priority, oldest first.
+func (m *Manager) next(ctx context.Context) (*job, context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var claimed *job
+	for _, j := range m.jobs {
+		if j.State == Queued && (claimed == nil || j.Priority > claimed.Priority) {
+			claimed = j
+		}
+	}
+	if claimed == nil {
+		return nil, nil
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	claimed.cancel = cancel
+	claimed.stopAs = Active
+	claimed.State = Active
+	claimed.Progress = Progress{}
+	return claimed, jobCtx
+}
+
+func (m *Manager) work(ctx context.Context) {
+	for {
+		j, jobCtx := m.next(ctx)
+		if j == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.wake:
+			}
+			continue
+		}
+
+		m.mu.Lock()
+		item := j.Item
+		m.mu.Unlock()
+		select {
+		case m.updates <- item:
+		case <-ctx.Done():
+			return
+		}
+
+		file, err := m.fetch(jobCtx, j, item)
+		j.cancel()
+
+		m.mu.Lock()
+		switch {
+		case j.stopAs != Active:
+			j.State = j.stopAs
+			if j.State == Canceled {
+				m.discard(j)
+			}
+		case err != nil:
+			j.State = Failed
+			j.Err = err
+		default:
+			j.State = Done
+			j.File = file
+		}
+		item = j.Item
+		m.mu.Unlock()
+
+		// more than one item may have been queued while this one ran
+		m.signal()
+		select {
+		case m.updates <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) fetch(ctx context.Context, j *job, item Item) (string, error) {
+	resolvers := m.resolvers
+	if resolvers == nil {
+		resolvers = Resolvers
+	}
+	resolved, err := resolvers.Resolve(ctx, item.URL)
+	if err != nil {
+		return "", &DownloadError{URL: item.URL, Err: err}
+	}
+	if resolved.External {
+		return downloadFromYTTo(ctx, resolved.URL, item.Dir, item.Fallback)
+	}
+	m.mu.Lock()
+	j.fetched = resolved.URL
+	m.mu.Unlock()
+	var sent time.Time
+	onProgress := func(progress Progress) {
+		m.mu.Lock()
+		j.Progress = progress
+		item := j.Item
+		m.mu.Unlock()
+
+		// progress is dropped rather than blocking when the channel is full
+		if time.Since(sent) >= progressInterval {
+			sent = time.Now()
+			select {
+			case m.updates <- item:
+			default:
+			}
+		}
+	}
+	file, err := downloadFileTo(ctx, request{
+		url:        resolved.URL,
+		header:     resolved.Header,
+		dir:        item.Dir,
+		suggested:  resolved.Filename,
+		fallback:   item.Fallback,
+		onProgress: onProgress,
+		verify:     m.verify,
+		client:     resolved.Client,
+	})
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		err = ctx.Err()
+	}
+	return file, err
+}
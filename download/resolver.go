@@ -0,0 +1,289 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Resolved is a direct download for a tracker link.
+type Resolved struct {
+	URL    string
+	Header http.Header
+	// Filename is used when the server doesn't send one.
+	Filename string
+	// External links are handed to yt-dlp instead of being fetched.
+	External bool
+	// Client fetches the download, HTTPClient when nil.
+	Client *http.Client
+}
+
+// Resolver turns links for one file host into direct downloads. Resolve is
+// only called with links Match accepted.
+type Resolver interface {
+	Name() string
+	Match(u *url.URL) bool
+	Resolve(ctx context.Context, u *url.URL) (Resolved, error)
+}
+
+var ErrUnsupportedLink = errors.New("unsupported link")
+
+// Registry is an ordered list of resolvers, the first one to match a link
+// resolves it.
+type Registry []Resolver
+
+// Resolvers is the registry downloads are resolved with.
+var Resolvers = Registry{
+	&PillowcaseResolver{},
+	&YouTubeResolver{},
+	&DriveResolver{},
+	&DropboxResolver{},
+	&DirectResolver{},
+}
+
+// Register adds a resolver ahead of the generic direct-URL one.
+func (reg *Registry) Register(r Resolver) {
+	i := len(*reg)
+	if i > 0 {
+		if _, ok := (*reg)[i-1].(*DirectResolver); ok {
+			i--
+		}
+	}
+	*reg = append((*reg)[:i], append(Registry{r}, (*reg)[i:]...)...)
+}
+
+// Resolve finds the resolver for link and resolves it.
+func (reg Registry) Resolve(ctx context.Context, link string) (Resolved, error) {
+	u, r, err := reg.resolverFor(link)
+	if err != nil {
+		return Resolved{}, err
+	}
+	resolved, err := r.Resolve(ctx, u)
+	if err != nil {
+		return Resolved{}, fmt.Errorf("%s: %w", r.Name(), err)
+	}
+	return resolved, nil
+}
+
+// CanResolve reports whether some resolver accepts link.
+func (reg Registry) CanResolve(link string) bool {
+	_, _, err := reg.resolverFor(link)
+	return err == nil
+}
+
+func (reg Registry) resolverFor(link string) (*url.URL, Resolver, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, nil, ErrUnsupportedLink
+	}
+	for _, r := range reg {
+		if r.Match(u) {
+			return u, r, nil
+		}
+	}
+	return nil, nil, ErrUnsupportedLink
+}
+
+// Register adds a resolver to Resolvers, see Registry.Register.
+func Register(r Resolver) {
+	Resolvers.Register(r)
+}
+
+func Resolve(ctx context.Context, link string) (Resolved, error) {
+	return Resolvers.Resolve(ctx, link)
+}
+
+func CanResolve(link string) bool {
+	return Resolvers.CanResolve(link)
+}
+
+// hostIs reports whether u is on domain or one of its subdomains.
+func hostIs(u *url.URL, domains ...string) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// withBase moves u onto base's scheme and host, keeping its path and query.
+func withBase(base string, u *url.URL) (*url.URL, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	rebased := *u
+	rebased.Scheme, rebased.Host = b.Scheme, b.Host
+	rebased.Path = strings.TrimSuffix(b.Path, "/") + u.Path
+	rebased.RawPath = ""
+	return &rebased, nil
+}
+
+// PillowcaseResolver handles pillowcase "/f/<id>" links.
+type PillowcaseResolver struct {
+	// APIBase defaults to https://api.pillowcase.su
+	APIBase string
+	Client  *http.Client
+}
+
+func (*PillowcaseResolver) Name() string { return "pillowcase" }
+
+func (*PillowcaseResolver) Match(u *url.URL) bool {
+	return hostIs(u, "pillowcase.su", "pillows.su") && !hostIs(u, "api.pillowcase.su")
+}
+
+func (r *PillowcaseResolver) Resolve(ctx context.Context, u *url.URL) (Resolved, error) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] != "f" || parts[1] == "" {
+		return Resolved{}, fmt.Errorf("unexpected URL format")
+	}
+	base := r.APIBase
+	if base == "" {
+		base = "https://api.pillowcase.su"
+	}
+	return Resolved{
+		URL:    strings.TrimSuffix(base, "/") + "/api/download/" + url.PathEscape(parts[1]),
+		Client: r.Client,
+	}, nil
+}
+
+// YouTubeResolver hands YouTube links to yt-dlp, rewritten to a plain watch
+// link so playlist and timestamp parameters are dropped.
+type YouTubeResolver struct {
+	// WatchBase defaults to https://www.youtube.com
+	WatchBase string
+}
+
+func (*YouTubeResolver) Name() string { return "youtube" }
+
+func (*YouTubeResolver) Match(u *url.URL) bool {
+	return hostIs(u, "youtube.com", "youtu.be")
+}
+
+func (r *YouTubeResolver) Resolve(ctx context.Context, u *url.URL) (Resolved, error) {
+	// https://youtu.be/<id>, .../watch?v=<id> or .../shorts/<id>
+	id := u.Query().Get("v")
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case id != "":
+	case hostIs(u, "youtu.be") && len(parts) == 1:
+		id = parts[0]
+	case len(parts) == 2 && (parts[0] == "shorts" || parts[0] == "embed" || parts[0] == "live"):
+		id = parts[1]
+	}
+	if id == "" {
+		return Resolved{}, fmt.Errorf("no video id in link")
+	}
+	base := r.WatchBase
+	if base == "" {
+		base = "https://www.youtube.com"
+	}
+	return Resolved{URL: strings.TrimSuffix(base, "/") + "/watch?v=" + url.QueryEscape(id), External: true}, nil
+}
+
+// DriveResolver handles Google Drive file links.
+type DriveResolver struct {
+	// DownloadBase defaults to https://drive.usercontent.google.com
+	DownloadBase string
+	Client       *http.Client
+}
+
+func (*DriveResolver) Name() string { return "google drive" }
+
+func (*DriveResolver) Match(u *url.URL) bool {
+	return hostIs(u, "drive.google.com", "drive.usercontent.google.com")
+}
+
+func (r *DriveResolver) Resolve(ctx context.Context, u *url.URL) (Resolved, error) {
+	// https://drive.google.com/file/d/<id>/view or .../open?id=<id>
+	id := u.Query().Get("id")
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; id == "" && i+2 < len(parts); i++ {
+		if parts[i] == "file" && parts[i+1] == "d" {
+			id = parts[i+2]
+		}
+	}
+	if id == "" {
+		return Resolved{}, fmt.Errorf("no file id in link")
+	}
+	base := r.DownloadBase
+	if base == "" {
+		base = "https://drive.usercontent.google.com"
+	}
+	query := url.Values{"id": {id}, "export": {"download"}, "confirm": {"t"}}
+	return Resolved{URL: strings.TrimSuffix(base, "/") + "/download?" + query.Encode(), Client: r.Client}, nil
+}
+
+// DropboxResolver turns Dropbox share links into direct downloads.
+type DropboxResolver struct {
+	// DownloadBase defaults to https://dl.dropboxusercontent.com
+	DownloadBase string
+	Client       *http.Client
+}
+
+func (*DropboxResolver) Name() string { return "dropbox" }
+
+func (*DropboxResolver) Match(u *url.URL) bool {
+	return hostIs(u, "dropbox.com")
+}
+
+func (r *DropboxResolver) Resolve(ctx context.Context, u *url.URL) (Resolved, error) {
+	base := r.DownloadBase
+	if base == "" {
+		base = "https://dl.dropboxusercontent.com"
+	}
+	direct, err := withBase(base, u)
+	if err != nil {
+		return Resolved{}, err
+	}
+	query := direct.Query()
+	query.Set("dl", "1")
+	direct.RawQuery = query.Encode()
+	return Resolved{URL: direct.String(), Filename: baseName(u), Client: r.Client}, nil
+}
+
+// directExtensions are the files DirectResolver downloads as they are.
+var directExtensions = []string{".mp3", ".flac", ".wav", ".ogg", ".m4a", ".aac", ".opus", ".zip"}
+
+// DirectResolver downloads links that point straight at an audio file or
+// archive.
+type DirectResolver struct {
+	Client *http.Client
+}
+
+func (*DirectResolver) Name() string { return "direct" }
+
+func (*DirectResolver) Match(u *url.URL) bool {
+	ext := strings.ToLower(path.Ext(u.Path))
+	for _, known := range directExtensions {
+		if ext == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *DirectResolver) Resolve(ctx context.Context, u *url.URL) (Resolved, error) {
+	return Resolved{URL: u.String(), Filename: baseName(u), Client: r.Client}, nil
+}
+
+// baseName is the last element of the link's path.
+func baseName(u *url.URL) string {
+	name := path.Base(u.Path)
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	if name == "/" || name == "." {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,208 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeHost records the requests a resolved download makes.
+type fakeHost struct {
+	*httptest.Server
+	requests []*url.URL
+}
+
+// newFakeHost serves a small MP3 over TLS, so only a resolver's injected
+// client can reach it.
+func newFakeHost(t *testing.T) *fakeHost {
+	t.Helper()
+	host := &fakeHost{}
+	host.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host.requests = append(host.requests, r.URL)
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("ID3 not really a song"))
+	}))
+	t.Cleanup(host.Close)
+	return host
+}
+
+// registryFor points every resolver at host.
+func registryFor(host *fakeHost) Registry {
+	client := host.Client()
+	return Registry{
+		&PillowcaseResolver{APIBase: host.URL, Client: client},
+		&YouTubeResolver{WatchBase: host.URL},
+		&DriveResolver{DownloadBase: host.URL, Client: client},
+		&DropboxResolver{DownloadBase: host.URL, Client: client},
+		&DirectResolver{Client: client},
+	}
+}
+
+func TestDefaultResolvers(t *testing.T) {
+	tests := []struct {
+		link     string
+		want     string
+		filename string
+		external bool
+	}{
+		{"https://pillowcase.su/f/abc123", "https://api.pillowcase.su/api/download/abc123", "", false},
+		{"https://pillows.su/f/abc123/", "https://api.pillowcase.su/api/download/abc123", "", false},
+		{"https://youtu.be/dQw4w9WgXcQ?t=10", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", "", true},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PL1", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", "", true},
+		{"https://youtube.com/shorts/dQw4w9WgXcQ", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", "", true},
+		{"https://drive.google.com/file/d/XYZ/view?usp=sharing", "https://drive.usercontent.google.com/download?confirm=t&export=download&id=XYZ", "", false},
+		{"https://drive.google.com/open?id=XYZ", "https://drive.usercontent.google.com/download?confirm=t&export=download&id=XYZ", "", false},
+		{"https://www.dropbox.com/s/abc/My%20Song.mp3?dl=0", "https://dl.dropboxusercontent.com/s/abc/My%20Song.mp3?dl=1", "My Song.mp3", false},
+		{"https://files.example.com/a/Song%20(v2).flac", "https://files.example.com/a/Song%20(v2).flac", "Song (v2).flac", false},
+	}
+	for _, test := range tests {
+		resolved, err := Resolve(context.Background(), test.link)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", test.link, err)
+			continue
+		}
+		if resolved.URL != test.want || resolved.Filename != test.filename || resolved.External != test.external {
+			t.Errorf("Resolve(%q) = %q %q external=%v, want %q %q external=%v",
+				test.link, resolved.URL, resolved.Filename, resolved.External, test.want, test.filename, test.external)
+		}
+	}
+}
+
+func TestUnsupportedLinks(t *testing.T) {
+	for _, link := range []string{
+		"https://example.com/some/page",
+		"ftp://example.com/song.mp3",
+		"not a link",
+		"https://pillowcase.su/album/123",
+		"https://drive.google.com/drive/folders/abc",
+	} {
+		if _, err := Resolve(context.Background(), link); err == nil {
+			t.Errorf("Resolve(%q) succeeded", link)
+		}
+	}
+	if CanResolve("https://example.com/some/page") {
+		t.Error("CanResolve accepted a web page")
+	}
+	if _, err := Resolve(context.Background(), "https://example.com/page"); !errors.Is(err, ErrUnsupportedLink) {
+		t.Errorf("got %v, want ErrUnsupportedLink", err)
+	}
+}
+
+// hostResolver claims every link on one host.
+type hostResolver struct{ host string }
+
+func (hostResolver) Name() string { return "test" }
+
+func (r hostResolver) Match(u *url.URL) bool { return u.Host == r.host }
+
+func (r hostResolver) Resolve(ctx context.Context, u *url.URL) (Resolved, error) {
+	return Resolved{URL: "https://cdn.example.com" + u.Path}, nil
+}
+
+func TestRegisterGoesBeforeDirect(t *testing.T) {
+	reg := Registry{&PillowcaseResolver{}, &DirectResolver{}}
+	reg.Register(hostResolver{host: "songs.example.com"})
+	if len(reg) != 3 {
+		t.Fatalf("%d resolvers", len(reg))
+	}
+	if _, ok := reg[2].(*DirectResolver); !ok {
+		t.Fatalf("the direct resolver moved to %T", reg[2])
+	}
+
+	// the registered resolver wins over the direct one for its host
+	resolved, err := reg.Resolve(context.Background(), "https://songs.example.com/a.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.URL != "https://cdn.example.com/a.mp3" {
+		t.Fatalf("resolved to %q", resolved.URL)
+	}
+}
+
+func TestResolversAgainstFakeHost(t *testing.T) {
+	host := newFakeHost(t)
+	reg := registryFor(host)
+	tests := []struct {
+		link     string
+		path     string
+		query    string
+		filename string
+	}{
+		{"https://pillowcase.su/f/abc123", "/api/download/abc123", "", "fallback"},
+		{"https://drive.google.com/file/d/XYZ/view", "/download", "confirm=t&export=download&id=XYZ", "fallback"},
+		{"https://www.dropbox.com/s/abc/song.mp3?dl=0", "/s/abc/song.mp3", "dl=1", "song.mp3"},
+		{host.URL + "/files/track.mp3", "/files/track.mp3", "", "track.mp3"},
+	}
+	for _, test := range tests {
+		host.requests = nil
+		resolved, err := reg.Resolve(context.Background(), test.link)
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", test.link, err)
+		}
+		dir := t.TempDir()
+		filename, err := downloadFileTo(context.Background(), request{
+			url:       resolved.URL,
+			header:    resolved.Header,
+			dir:       dir,
+			suggested: resolved.Filename,
+			fallback:  "fallback",
+			client:    resolved.Client,
+		})
+		if err != nil {
+			t.Fatalf("downloading %q: %v", test.link, err)
+		}
+		if len(host.requests) != 1 {
+			t.Fatalf("%q made %d requests", test.link, len(host.requests))
+		}
+		if got := host.requests[0]; got.Path != test.path || got.RawQuery != test.query {
+			t.Errorf("%q requested %s?%s, want %s?%s", test.link, got.Path, got.RawQuery, test.path, test.query)
+		}
+		if filename != test.filename {
+			t.Errorf("%q saved as %q, want %q", test.link, filename, test.filename)
+		}
+	}
+
+	resolved, err := reg.Resolve(context.Background(), "https://youtu.be/abc")
+	if err != nil || !resolved.External || resolved.URL != host.URL+"/watch?v=abc" {
+		t.Errorf("youtube resolved to %+v, %v", resolved, err)
+	}
+}
+
+func TestManagerResolvesLinks(t *testing.T) {
+	host := newFakeHost(t)
+	m := NewManager(1, nil)
+	m.resolvers = registryFor(host)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Start(ctx)
+
+	dir := t.TempDir()
+	id := m.Add("https://pillowcase.su/f/abc123", dir, "song", PriorityNormal)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case item := <-m.Updates():
+			if item.ID != id || !item.State.Finished() {
+				continue
+			}
+			if item.State != Done {
+				t.Fatalf("download %s: %v", item.State, item.Err)
+			}
+			if item.URL != "https://pillowcase.su/f/abc123" {
+				t.Errorf("item URL changed to %q", item.URL)
+			}
+			if _, err := os.Stat(filepath.Join(dir, item.File)); err != nil {
+				t.Fatal(err)
+			}
+			return
+		case <-timeout:
+			t.Fatal("download never finished")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// partial is kept next to an unfinished <file>.tmp so the download can pick
+// up where it stopped.
+type partial struct {
+	URL          string
+	File         string
+	ETag         string
+	LastModified string
+	Size         uint64
+}
+
+// partials are found by URL, the file name is only known once the server
+// answers
+func partialPath(dir string, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, ".partial-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+func readPartial(dir string, url string) (partial, bool) {
+	var p partial
+	data, err := os.ReadFile(partialPath(dir, url))
+	if err != nil || json.Unmarshal(data, &p) != nil || p.URL != url || p.File == "" {
+		return partial{}, false
+	}
+	return p, true
+}
+
+func writePartial(dir string, p partial) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partialPath(dir, p.URL), data, 0644)
+}
+
+// removePartial forgets an unfinished download, its .tmp file included.
+func removePartial(dir string, p partial) {
+	os.Remove(partialPath(dir, p.URL))
+	if p.File != "" {
+		os.Remove(filepath.Join(dir, p.File+".tmp"))
+	}
+}
+
+// discardPartial removes whatever an unfinished download of url left in dir.
+func discardPartial(dir string, url string) {
+	if p, ok := readPartial(dir, url); ok {
+		removePartial(dir, p)
+	}
+}
+
+// validator is what If-Range is checked against. Weak ETags can't be used
+// for ranges, Last-Modified is the fallback.
+func (p partial) validator() string {
+	if p.ETag != "" && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+// parseContentRange reads "bytes 100-199/200", the size is 0 for "*".
+func parseContentRange(header string) (start uint64, size uint64, ok bool) {
+	rest, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	span, total, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseUint(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if total != "*" {
+		if size, err = strconv.ParseUint(total, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, size, true
+}
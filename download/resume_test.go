@@ -0,0 +1,248 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var song = bytes.Repeat([]byte("0123456789"), 1000)
+
+// startPartial leaves a download of url in dir as if it had been
+// interrupted after n bytes.
+func startPartial(t *testing.T, dir string, url string, n int, etag string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "song.mp3.tmp"), song[:n], 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := partial{URL: url, File: "song.mp3", ETag: etag, Size: uint64(len(song))}
+	if err := writePartial(dir, p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveSong serves song with the given ETag, Range and If-Range included,
+// recording the Range header of every request.
+func serveSong(etag string, ranges *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("Content-Disposition", `attachment; filename="song.mp3"`)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(song))
+	}))
+}
+
+func checkDownload(t *testing.T, dir string, filename string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filename != "song.mp3" {
+		t.Fatalf("saved as %q", filename)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, song) {
+		t.Fatalf("got %d bytes, want the %d byte song", len(data), len(song))
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(dir, ".partial-*"))
+	tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(leftovers)+len(tmps) > 0 {
+		t.Fatalf("left behind %v %v", leftovers, tmps)
+	}
+}
+
+func TestResumeWithRange(t *testing.T) {
+	var ranges []string
+	srv := serveSong(`"v1"`, &ranges)
+	defer srv.Close()
+	dir := t.TempDir()
+	url := srv.URL + "/f/song"
+	startPartial(t, dir, url, 4000, `"v1"`)
+
+	var resumedAt uint64
+	filename, err := downloadFileTo(context.Background(), request{
+		url: url,
+		dir: dir,
+		onProgress: func(p Progress) {
+			if resumedAt == 0 {
+				resumedAt = p.Written
+			}
+		},
+	})
+	checkDownload(t, dir, filename, err)
+	if len(ranges) != 1 || ranges[0] != "bytes=4000-" {
+		t.Fatalf("requests sent ranges %q, want one for bytes=4000-", ranges)
+	}
+	if resumedAt <= 4000 {
+		t.Fatalf("progress started at %d, want it to count the resumed bytes", resumedAt)
+	}
+}
+
+func TestResumeSendsIfRange(t *testing.T) {
+	var ifRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifRange = r.Header.Get("If-Range")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "song.mp3", time.Time{}, bytes.NewReader(song))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	startPartial(t, dir, srv.URL, 10, `"v1"`)
+
+	filename, err := downloadFileTo(context.Background(), request{url: srv.URL, dir: dir})
+	checkDownload(t, dir, filename, err)
+	if ifRange != `"v1"` {
+		t.Fatalf("If-Range = %q", ifRange)
+	}
+}
+
+func TestRestartWhenRangeIgnored(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// answers every request with the whole file
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Disposition", `attachment; filename="song.mp3"`)
+		w.Header().Set("Content-Length", fmt.Sprint(len(song)))
+		w.Write(song)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	startPartial(t, dir, srv.URL, 4000, `"v1"`)
+
+	filename, err := downloadFileTo(context.Background(), request{url: srv.URL, dir: dir})
+	checkDownload(t, dir, filename, err)
+	if requests != 1 {
+		t.Fatalf("%d requests, the 200 should have been used as it is", requests)
+	}
+}
+
+func TestRestartWhenFileChanged(t *testing.T) {
+	var ranges []string
+	srv := serveSong(`"v2"`, &ranges)
+	defer srv.Close()
+	dir := t.TempDir()
+	url := srv.URL + "/f/song"
+	// the partial was started against an older version of the file
+	startPartial(t, dir, url, 4000, `"v1"`)
+	if err := os.WriteFile(filepath.Join(dir, "song.mp3.tmp"), bytes.Repeat([]byte("x"), 4000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	filename, err := downloadFileTo(context.Background(), request{url: url, dir: dir})
+	checkDownload(t, dir, filename, err)
+}
+
+func TestRestartOnWrongContentRange(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Disposition", `attachment; filename="song.mp3"`)
+		if r.Header.Get("Range") != "" {
+			// a range other than the one asked for
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-9/%d", len(song)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(song[:10])
+			return
+		}
+		w.Write(song)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	startPartial(t, dir, srv.URL, 4000, `"v1"`)
+
+	filename, err := downloadFileTo(context.Background(), request{url: srv.URL, dir: dir})
+	checkDownload(t, dir, filename, err)
+	if len(requests) != 2 || requests[1] != "" {
+		t.Fatalf("ranges requested %q, want a restart without one", requests)
+	}
+}
+
+func TestRestartOnlyOnce(t *testing.T) {
+	dir := t.TempDir()
+	var requests int
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// as if the partial file could not be removed
+		startPartial(t, dir, srv.URL, 4000, `"v1"`)
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+	}))
+	defer srv.Close()
+	startPartial(t, dir, srv.URL, 4000, `"v1"`)
+
+	_, err := downloadFileTo(context.Background(), request{url: srv.URL, dir: dir})
+	var dlErr *DownloadError
+	if !errors.As(err, &dlErr) || dlErr.Status != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("got %v, want a DownloadError for the 416", err)
+	}
+	if requests != 2 {
+		t.Fatalf("made %d requests, want the first and one restart", requests)
+	}
+}
+
+func TestPartialForAnotherURL(t *testing.T) {
+	var ranges []string
+	srv := serveSong(`"v1"`, &ranges)
+	defer srv.Close()
+	dir := t.TempDir()
+	startPartial(t, dir, srv.URL+"/other", 4000, `"v1"`)
+	// metadata recorded for a different URL is ignored
+	data, _ := os.ReadFile(partialPath(dir, srv.URL+"/other"))
+	os.WriteFile(partialPath(dir, srv.URL+"/song"), data, 0644)
+
+	filename, err := downloadFileTo(context.Background(), request{url: srv.URL + "/song", dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filename != "song.mp3" {
+		t.Fatalf("saved as %q", filename)
+	}
+	if len(ranges) != 1 || ranges[0] != "" {
+		t.Fatalf("ranges requested %q, want none", ranges)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, filename))
+	if !bytes.Equal(data, song) {
+		t.Fatalf("got %d bytes", len(data))
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header      string
+		start, size uint64
+		ok          bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-9/*", 0, 0, true},
+		{"bytes */200", 0, 0, false},
+		{"items 0-9/10", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, test := range tests {
+		start, size, ok := parseContentRange(test.header)
+		if start != test.start || size != test.size || ok != test.ok {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v", test.header, start, size, ok)
+		}
+	}
+}
+
+func TestValidatorSkipsWeakETags(t *testing.T) {
+	p := partial{ETag: `W/"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}
+	if v := p.validator(); !strings.HasSuffix(v, "GMT") {
+		t.Fatalf("validator = %q, want Last-Modified", v)
+	}
+}
@@ -0,0 +1,107 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type SheetRef struct {
+	ID   string
+	GIDs []string
+}
+
+type SheetTab struct {
+	Name string
+	GID  string
+}
+
+var (
+	sheetIDRe  = regexp.MustCompile(`docs\.google\.com/spreadsheets/d/([a-zA-Z0-9-_]+)`)
+	sheetGIDRe = regexp.MustCompile(`gid=([0-9]+)`)
+	bareGIDRe  = regexp.MustCompile(`^[0-9]+$`)
+	// tabs are listed in the htmlview page as items.push({name: "...", pageUrl: "...", gid: "..."})
+	sheetTabRe = regexp.MustCompile(`name:\s*"((?:[^"\\]|\\.)*)",\s*pageUrl:\s*"(?:[^"\\]|\\.)*",\s*gid:\s*"([0-9]+)"`)
+)
+
+// SheetsBaseURL is where spreadsheets are fetched from, swapped out when
+// testing against a local server.
+var SheetsBaseURL = "https://docs.google.com"
+
+// ParseSheetURL reads the spreadsheet id and any gids from what was pasted
+// into the import box. Several gids can be given, either in the URL itself
+// or as extra numbers after it: "<url> 123 456" or "<url> 123,456".
+func ParseSheetURL(input string) (SheetRef, error) {
+	var ref SheetRef
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\n' || r == '\t'
+	})
+
+	seen := make(map[string]bool)
+	addGID := func(gid string) {
+		if !seen[gid] {
+			seen[gid] = true
+			ref.GIDs = append(ref.GIDs, gid)
+		}
+	}
+
+	for _, field := range fields {
+		if matches := sheetIDRe.FindStringSubmatch(field); matches != nil {
+			if ref.ID == "" {
+				ref.ID = matches[1]
+			}
+			for _, gid := range sheetGIDRe.FindAllStringSubmatch(field, -1) {
+				addGID(gid[1])
+			}
+			continue
+		}
+		if bareGIDRe.MatchString(field) {
+			addGID(field)
+		}
+	}
+
+	if ref.ID == "" {
+		return ref, fmt.Errorf("invalid Google Sheets URL")
+	}
+	return ref, nil
+}
+
+func SheetExportURL(spreadsheetID string, gid string) string {
+	return fmt.Sprintf("%s/spreadsheets/d/%s/export?format=csv&gid=%s", SheetsBaseURL, spreadsheetID, gid)
+}
+
+// DiscoverSheetTabs lists the tabs of a public spreadsheet by reading its
+// htmlview page.
+func DiscoverSheetTabs(spreadsheetID string) ([]SheetTab, error) {
+	resp, err := HTTPClient.Get(fmt.Sprintf("%s/spreadsheets/d/%s/htmlview", SheetsBaseURL, spreadsheetID))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing tabs: %s", resp.Status)
+	}
+
+	page, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var tabs []SheetTab
+	seen := make(map[string]bool)
+	for _, match := range sheetTabRe.FindAllStringSubmatch(string(page), -1) {
+		if seen[match[2]] {
+			continue
+		}
+		seen[match[2]] = true
+		name, err := strconv.Unquote(`"` + match[1] + `"`)
+		if err != nil {
+			name = match[1]
+		}
+		tabs = append(tabs, SheetTab{Name: name, GID: match[2]})
+	}
+	return tabs, nil
+}
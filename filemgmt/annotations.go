@@ -0,0 +1,142 @@
+package filemgmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const annotationsName = "annotations.json"
+
+type LinkStatus string
+
+const (
+	LinkUnchecked LinkStatus = ""
+	LinkVerified  LinkStatus = "verified"
+	LinkBroken    LinkStatus = "broken"
+)
+
+// Annotation is what we note down about an entry ourselves. Annotations are
+// kept next to the tracker, keyed by AnnotationKeys, so refreshing the sheet
+// doesn't lose them.
+type Annotation struct {
+	Note    string
+	Tags    []string
+	Link    LinkStatus
+	Updated time.Time
+}
+
+func (a Annotation) Empty() bool {
+	return a.Note == "" && len(a.Tags) == 0 && a.Link == LinkUnchecked
+}
+
+// Marker sums an annotation up for a table cell.
+func (a Annotation) Marker() string {
+	var parts []string
+	switch a.Link {
+	case LinkVerified:
+		parts = append(parts, "✓")
+	case LinkBroken:
+		parts = append(parts, "✗")
+	}
+	if a.Note != "" {
+		parts = append(parts, "✎")
+	}
+	for _, tag := range a.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	return strings.Join(parts, " ")
+}
+
+type Annotations map[string]Annotation
+
+// AnnotationKeys gives each entry of the tab, by line, the key its
+// annotation is stored under: the tab, the entry's Key and, for rows that
+// repeat it, which repeat it is.
+func (t Tracker) AnnotationKeys() map[int]string {
+	keys := make(map[int]string)
+	seen := make(map[string]int)
+	for _, entry := range t.Entries {
+		key := t.Tab + "|" + entry.Key()
+		seen[key]++
+		if seen[key] > 1 {
+			key = fmt.Sprintf("%s#%d", key, seen[key])
+		}
+		keys[entry.Line] = key
+	}
+	return keys
+}
+
+func (a Annotations) For(key string) Annotation {
+	return a[key]
+}
+
+// Set stores the annotation under key, removing it once it's empty.
+func (a Annotations) Set(key string, annotation Annotation) {
+	if annotation.Empty() {
+		delete(a, key)
+		return
+	}
+	annotation.Updated = time.Now()
+	a[key] = annotation
+}
+
+// Migrate moves annotations saved under a bare Entry.Key, before keys had a
+// tab, to the first entry with that key. It reports whether anything moved.
+func (a Annotations) Migrate(tabs []Tracker) bool {
+	moved := make(map[string]bool)
+	for _, tab := range tabs {
+		keys := tab.AnnotationKeys()
+		for _, entry := range tab.Entries {
+			old := entry.Key()
+			annotation, ok := a[old]
+			if !ok || moved[old] {
+				continue
+			}
+			if _, taken := a[keys[entry.Line]]; !taken {
+				a[keys[entry.Line]] = annotation
+			}
+			moved[old] = true
+		}
+	}
+	for old := range moved {
+		delete(a, old)
+	}
+	return len(moved) > 0
+}
+
+// ParseTags splits a comma or space separated tag list.
+func ParseTags(input string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if tag = strings.TrimPrefix(tag, "#"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func ReadAnnotations(name string) (Annotations, error) {
+	annotations := make(Annotations)
+	data, err := os.ReadFile(sidecarPath(name, annotationsName))
+	if os.IsNotExist(err) {
+		return annotations, nil
+	}
+	if err != nil {
+		return annotations, err
+	}
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return make(Annotations), fmt.Errorf("invalid annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+func WriteAnnotations(name string, annotations Annotations) error {
+	data, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sidecarPath(name, annotationsName), data, 0644)
+}
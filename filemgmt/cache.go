@@ -0,0 +1,317 @@
+package filemgmt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const songCacheName = "index.json"
+
+// CachedSong is a downloaded song, stored in SongsDir under its content hash
+// so songs that share a file name don't overwrite each other.
+type CachedSong struct {
+	// Link is the tracker link the song was downloaded from.
+	Link    string
+	Tracker string
+	Era     string
+	// Entry is the entry's Key.
+	Entry string
+	Title string
+	// File is the name inside SongsDir.
+	File    string
+	Hash    string
+	Size    int64
+	Format  string
+	Fetched time.Time
+	// LastPlayed is zero until the song is played from the cache.
+	LastPlayed time.Time
+	// Pinned songs are never evicted.
+	Pinned bool
+}
+
+// LastUsed is when the song was last downloaded or played.
+func (s CachedSong) LastUsed() time.Time {
+	if s.LastPlayed.After(s.Fetched) {
+		return s.LastPlayed
+	}
+	return s.Fetched
+}
+
+func (s CachedSong) Path() string {
+	return filepath.Join(SongsDir(), s.File)
+}
+
+// SongCache maps the links songs were downloaded from to the stored files.
+type SongCache map[string]CachedSong
+
+// cacheMu serializes changes to the index, downloads finish concurrently.
+var cacheMu sync.Mutex
+
+// IncomingDir is where downloads land before they are added to the cache.
+func IncomingDir() string {
+	return filepath.Join(SongsDir(), ".incoming")
+}
+
+// IncomingDirFor is the directory a download of link is staged in. Every link
+// gets its own, so songs that share a title can't overwrite each other before
+// they are hashed.
+func IncomingDirFor(link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return filepath.Join(IncomingDir(), hex.EncodeToString(sum[:8]))
+}
+
+func songCachePath() string {
+	return filepath.Join(SongsDir(), songCacheName)
+}
+
+func ReadSongCache() (SongCache, error) {
+	cache := make(SongCache)
+	data, err := os.ReadFile(songCachePath())
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, err
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return cache, fmt.Errorf("invalid song cache index: %w", err)
+	}
+	return cache, nil
+}
+
+func writeSongCache(cache SongCache) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(SongsDir(), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := songCachePath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, songCachePath())
+}
+
+// Lookup returns the first of links whose song is cached and still on disk.
+func (c SongCache) Lookup(links []string) (CachedSong, bool) {
+	for _, link := range links {
+		song, ok := c[link]
+		if !ok {
+			continue
+		}
+		if _, err := os.Stat(song.Path()); err == nil {
+			return song, true
+		}
+	}
+	return CachedSong{}, false
+}
+
+// CachedSongFor looks the links up in the index on disk.
+func CachedSongFor(links []string) (CachedSong, bool) {
+	cache, err := ReadSongCache()
+	if err != nil {
+		return CachedSong{}, false
+	}
+	return cache.Lookup(links)
+}
+
+// AddToCache moves the downloaded file at path into the cache, named after
+// its SHA-256, and records it under song.Link.
+func AddToCache(path string, song CachedSong) (CachedSong, error) {
+	hash, size, err := hashFile(path)
+	if err != nil {
+		return song, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	song.Hash = hash
+	song.Size = size
+	song.Format = strings.TrimPrefix(ext, ".")
+	song.File = hash + ext
+	song.Fetched = time.Now()
+
+	err = updateSongCache(func(cache SongCache) error {
+		if err := os.Rename(path, song.Path()); err != nil {
+			return err
+		}
+		if dir := filepath.Dir(path); filepath.Dir(dir) == IncomingDir() {
+			// only goes once nothing else is staged in it
+			os.Remove(dir)
+		}
+		old, replaced := cache[song.Link]
+		cache[song.Link] = song
+		if replaced {
+			cache.removeUnused(old.File)
+		}
+		cache.fitLimit(song.Link)
+		return nil
+	})
+	return song, err
+}
+
+// updateSongCache applies change to the index and saves it.
+func updateSongCache(change func(SongCache) error) error {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if err := os.MkdirAll(SongsDir(), os.ModePerm); err != nil {
+		return err
+	}
+	cache, err := ReadSongCache()
+	if err != nil {
+		return err
+	}
+	if err := change(cache); err != nil {
+		return err
+	}
+	return writeSongCache(cache)
+}
+
+// TouchCachedSong marks a song as just played.
+func TouchCachedSong(link string) error {
+	return updateSongCache(func(cache SongCache) error {
+		if song, ok := cache[link]; ok {
+			song.LastPlayed = time.Now()
+			cache[link] = song
+		}
+		return nil
+	})
+}
+
+// PinCachedSong pins or unpins a song. An unpinned song can be evicted right
+// away when the cache is over its limit.
+func PinCachedSong(link string, pinned bool) error {
+	return updateSongCache(func(cache SongCache) error {
+		if song, ok := cache[link]; ok {
+			song.Pinned = pinned
+			cache[link] = song
+		}
+		if !pinned {
+			cache.fitLimit("")
+		}
+		return nil
+	})
+}
+
+// FitCacheLimit evicts songs until the cache fits CurrentSettings.CacheMaxMB,
+// for when the limit changed since the last download.
+func FitCacheLimit() error {
+	return updateSongCache(func(cache SongCache) error {
+		cache.fitLimit("")
+		return nil
+	})
+}
+
+// RemoveCachedSongs drops the songs from the index and deletes their files.
+func RemoveCachedSongs(links ...string) error {
+	return updateSongCache(func(cache SongCache) error {
+		for _, link := range links {
+			if song, ok := cache[link]; ok {
+				delete(cache, link)
+				cache.removeUnused(song.File)
+			}
+		}
+		return nil
+	})
+}
+
+// Size adds up the files in the cache, counting files shared by several
+// links once.
+func (c SongCache) Size() int64 {
+	var size int64
+	seen := make(map[string]bool)
+	for _, song := range c {
+		if !seen[song.File] {
+			seen[song.File] = true
+			size += song.Size
+		}
+	}
+	return size
+}
+
+// Songs lists the cache by tracker, era and title.
+func (c SongCache) Songs() []CachedSong {
+	songs := make([]CachedSong, 0, len(c))
+	for _, song := range c {
+		songs = append(songs, song)
+	}
+	sort.Slice(songs, func(i, j int) bool {
+		a, b := songs[i], songs[j]
+		if a.Tracker != b.Tracker {
+			return a.Tracker < b.Tracker
+		}
+		if a.Era != b.Era {
+			return a.Era < b.Era
+		}
+		return a.Title < b.Title
+	})
+	return songs
+}
+
+// fitLimit evicts songs past CurrentSettings.CacheMaxMB, keep is never evicted.
+func (c SongCache) fitLimit(keep string) {
+	if limit := int64(CurrentSettings.CacheMaxMB) << 20; limit > 0 {
+		c.evict(limit, keep)
+	}
+}
+
+// evict removes the least recently used songs that aren't pinned until the
+// cache fits in limit bytes. keep is never evicted.
+func (c SongCache) evict(limit int64, keep string) {
+	var candidates []CachedSong
+	for _, song := range c {
+		if !song.Pinned && song.Link != keep {
+			candidates = append(candidates, song)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].LastUsed().Before(candidates[j].LastUsed())
+	})
+	for _, song := range candidates {
+		if c.Size() <= limit {
+			return
+		}
+		delete(c, song.Link)
+		c.removeUnused(song.File)
+	}
+}
+
+// removeUnused deletes file once no record is stored in it.
+func (c SongCache) removeUnused(file string) {
+	if !c.uses(file) {
+		os.Remove(filepath.Join(SongsDir(), file))
+	}
+}
+
+// uses reports whether any record is stored in file.
+func (c SongCache) uses(file string) bool {
+	for _, song := range c {
+		if song.File == file {
+			return true
+		}
+	}
+	return false
+}
+
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
@@ -0,0 +1,62 @@
+package filemgmt
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type ParseWarning struct {
+	Line    int
+	Message string
+}
+
+func (w ParseWarning) String() string {
+	if w.Line <= 0 {
+		return w.Message
+	}
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+}
+
+// ReadRecords reads a CSV export as leniently as possible. A BOM is dropped,
+// bare carriage returns are treated as line breaks, stray quotes and ragged
+// rows are accepted, and rows that still can't be parsed are skipped with a
+// warning instead of aborting the whole file. lines holds the line each
+// record starts on.
+func ReadRecords(r io.Reader) (records [][]string, lines []int, warnings []ParseWarning, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if err != nil {
+			if !errors.As(err, &parseErr) {
+				return records, lines, warnings, err
+			}
+			warnings = append(warnings, ParseWarning{Line: parseErr.StartLine, Message: parseErr.Err.Error() + ", row skipped"})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	return records, lines, warnings, nil
+}
@@ -0,0 +1,159 @@
+package filemgmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const changesName = "changes.json"
+
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeLeaked  ChangeKind = "leaked"
+	ChangeLinks   ChangeKind = "links"
+	ChangeQuality ChangeKind = "quality"
+	ChangeType    ChangeKind = "type"
+)
+
+type EntryChange struct {
+	Kind  ChangeKind
+	Tab   string
+	Entry Entry
+	Old   string
+	New   string
+}
+
+// TrackerDiff is what changed between the previous and the current version
+// of a tracker, written next to the tracker every time it is refreshed.
+type TrackerDiff struct {
+	Refreshed time.Time
+	Changes   []EntryChange
+}
+
+// Key identifies an entry across refreshes by its era, title and version
+// tags, so reordered or re-linked rows still line up.
+func (e Entry) Key() string {
+	title := e.Title()
+	return strings.ToLower(FormatTitle(e.Era) + "|" + title.Base + "|" + strings.Join(title.Versions, ","))
+}
+
+// entriesByKey indexes entries by Key, numbering repeats so entries sharing a
+// title within an era don't collapse into one.
+func entriesByKey(entries []Entry) (map[string]Entry, []string) {
+	byKey := make(map[string]Entry)
+	var keys []string
+	seen := make(map[string]int)
+	for _, entry := range entries {
+		key := entry.Key()
+		seen[key]++
+		if seen[key] > 1 {
+			key = fmt.Sprintf("%s#%d", key, seen[key])
+		}
+		byKey[key] = entry
+		keys = append(keys, key)
+	}
+	return byKey, keys
+}
+
+func DiffTrackers(old, new Tracker) []EntryChange {
+	var changes []EntryChange
+	oldByKey, oldKeys := entriesByKey(old.Entries)
+	newByKey, newKeys := entriesByKey(new.Entries)
+
+	for _, key := range newKeys {
+		entry := newByKey[key]
+		before, ok := oldByKey[key]
+		if !ok {
+			changes = append(changes, EntryChange{Kind: ChangeAdded, Tab: new.Tab, Entry: entry})
+			continue
+		}
+
+		oldLinks, newLinks := before.LinkList(), entry.LinkList()
+		switch {
+		case len(oldLinks) == 0 && len(newLinks) > 0,
+			!before.ParsedLeakDate().Known() && entry.ParsedLeakDate().Known():
+			changes = append(changes, EntryChange{Kind: ChangeLeaked, Tab: new.Tab, Entry: entry, Old: before.LeakDate, New: entry.LeakDate})
+		case strings.Join(oldLinks, " ") != strings.Join(newLinks, " "):
+			changes = append(changes, EntryChange{Kind: ChangeLinks, Tab: new.Tab, Entry: entry, Old: strings.Join(oldLinks, " "), New: strings.Join(newLinks, " ")})
+		}
+		if before.Quality != entry.Quality {
+			changes = append(changes, EntryChange{Kind: ChangeQuality, Tab: new.Tab, Entry: entry, Old: before.Quality, New: entry.Quality})
+		}
+		if before.Type != entry.Type {
+			changes = append(changes, EntryChange{Kind: ChangeType, Tab: new.Tab, Entry: entry, Old: before.Type, New: entry.Type})
+		}
+	}
+
+	for _, key := range oldKeys {
+		if _, ok := newByKey[key]; !ok {
+			changes = append(changes, EntryChange{Kind: ChangeRemoved, Tab: old.Tab, Entry: oldByKey[key]})
+		}
+	}
+	return changes
+}
+
+// DiffTabs diffs each tab against the tab of the same name in the previous
+// version. Tabs that didn't exist before count as entirely new.
+func DiffTabs(old, new []Tracker) TrackerDiff {
+	diff := TrackerDiff{Refreshed: time.Now()}
+	oldTabs := make(map[string]Tracker)
+	for _, tab := range old {
+		oldTabs[tab.Tab] = tab
+	}
+	for _, tab := range new {
+		previous := oldTabs[tab.Tab]
+		previous.Tab = tab.Tab
+		diff.Changes = append(diff.Changes, DiffTrackers(previous, tab)...)
+		delete(oldTabs, tab.Tab)
+	}
+	for _, tab := range old {
+		if _, gone := oldTabs[tab.Tab]; gone {
+			diff.Changes = append(diff.Changes, DiffTrackers(tab, Tracker{Tab: tab.Tab})...)
+		}
+	}
+	return diff
+}
+
+// ChangesFor returns the changes of one tab, keyed by entry Key. An entry
+// with several changes keeps the most notable one.
+func (d TrackerDiff) ChangesFor(tab string) map[string]ChangeKind {
+	rank := map[ChangeKind]int{ChangeLeaked: 4, ChangeAdded: 3, ChangeLinks: 2, ChangeQuality: 1, ChangeType: 1}
+	changes := make(map[string]ChangeKind)
+	for _, change := range d.Changes {
+		if change.Tab != tab || change.Kind == ChangeRemoved {
+			continue
+		}
+		key := change.Entry.Key()
+		if existing, ok := changes[key]; !ok || rank[change.Kind] > rank[existing] {
+			changes[key] = change.Kind
+		}
+	}
+	return changes
+}
+
+func ReadChanges(dir string) (TrackerDiff, error) {
+	var diff TrackerDiff
+	data, err := os.ReadFile(filepath.Join(dir, changesName))
+	if err != nil {
+		return diff, err
+	}
+	if err := json.Unmarshal(data, &diff); err != nil {
+		return diff, fmt.Errorf("invalid changes file: %w", err)
+	}
+	return diff, nil
+}
+
+func WriteChanges(dir string, diff TrackerDiff) error {
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, changesName), data, 0644)
+}
@@ -0,0 +1,115 @@
+package filemgmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+// changeSummary lists changes as "kind name" for easy comparison.
+func changeSummary(changes []EntryChange) []string {
+	var summary []string
+	for _, change := range changes {
+		summary = append(summary, string(change.Kind)+" "+change.Entry.Name)
+	}
+	return summary
+}
+
+func TestEntryKey(t *testing.T) {
+	tests := []struct {
+		a, b Entry
+		same bool
+	}{
+		{Entry{Era: "Era", Name: "Song"}, Entry{Era: "ERA (2019)", Name: "song", Links: "https://a.com"}, true},
+		{Entry{Era: "Era", Name: "⭐ Song (feat. X)"}, Entry{Era: "Era", Name: "Song"}, true},
+		{Entry{Era: "Era", Name: "Song [V1]"}, Entry{Era: "Era", Name: "Song [V2]"}, false},
+		{Entry{Era: "One", Name: "Song"}, Entry{Era: "Two", Name: "Song"}, false},
+	}
+	for _, tt := range tests {
+		if same := tt.a.Key() == tt.b.Key(); same != tt.same {
+			t.Errorf("%q vs %q: same key %v, want %v", tt.a.Key(), tt.b.Key(), same, tt.same)
+		}
+	}
+}
+
+func TestDiffTrackers(t *testing.T) {
+	old := Tracker{Tab: "Main", Entries: []Entry{
+		{Era: "Era", Name: "Kept"},
+		{Era: "Era", Name: "Leaks", Quality: "Low"},
+		{Era: "Era", Name: "Relinked", Links: "https://a.com/1"},
+		{Era: "Era", Name: "Upgraded", Quality: "Low", Links: "https://a.com/2", Type: "Snippet"},
+		{Era: "Era", Name: "Gone"},
+		{Era: "Era", Name: "Twice", Links: "https://a.com/3"},
+	}}
+	new := Tracker{Tab: "Main", Entries: []Entry{
+		{Era: "Era", Name: "Kept"},
+		{Era: "Era", Name: "Leaks", Quality: "Low", Links: "https://a.com/4"},
+		{Era: "Era", Name: "Relinked", Links: "https://b.com/1"},
+		{Era: "Era", Name: "Upgraded", Quality: "CD", Links: "https://a.com/2", Type: "Full"},
+		{Era: "Era", Name: "Twice", Links: "https://a.com/3"},
+		{Era: "Era", Name: "Twice", Links: "https://a.com/5"},
+		{Era: "Era", Name: "New"},
+	}}
+
+	want := []string{
+		"leaked Leaks",
+		"links Relinked",
+		"quality Upgraded",
+		"type Upgraded",
+		"added Twice",
+		"added New",
+		"removed Gone",
+	}
+	changes := DiffTrackers(old, new)
+	if got := changeSummary(changes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("changes = %q, want %q", got, want)
+	}
+	for _, change := range changes {
+		if change.Tab != "Main" {
+			t.Errorf("%s %s on tab %q", change.Kind, change.Entry.Name, change.Tab)
+		}
+	}
+	if changes[2].Old != "Low" || changes[2].New != "CD" {
+		t.Errorf("quality change = %q -> %q", changes[2].Old, changes[2].New)
+	}
+}
+
+func TestDiffTrackersLeakDate(t *testing.T) {
+	old := Tracker{Entries: []Entry{{Era: "Era", Name: "Song", LeakDate: "?"}}}
+	new := Tracker{Entries: []Entry{{Era: "Era", Name: "Song", LeakDate: "03/14/2019"}}}
+	if got := changeSummary(DiffTrackers(old, new)); !reflect.DeepEqual(got, []string{"leaked Song"}) {
+		t.Errorf("changes = %q", got)
+	}
+}
+
+func TestDiffTabs(t *testing.T) {
+	old := []Tracker{
+		{Tab: "Main", Entries: []Entry{{Era: "Era", Name: "Song"}}},
+		{Tab: "Dropped", Entries: []Entry{{Era: "Era", Name: "Old"}}},
+	}
+	new := []Tracker{
+		{Tab: "Main", Entries: []Entry{{Era: "Era", Name: "Song"}}},
+		{Tab: "Added", Entries: []Entry{{Era: "Era", Name: "Fresh"}}},
+	}
+	diff := DiffTabs(old, new)
+	if got := changeSummary(diff.Changes); !reflect.DeepEqual(got, []string{"added Fresh", "removed Old"}) {
+		t.Fatalf("changes = %q", got)
+	}
+	if diff.Changes[0].Tab != "Added" || diff.Changes[1].Tab != "Dropped" {
+		t.Errorf("tabs = %q, %q", diff.Changes[0].Tab, diff.Changes[1].Tab)
+	}
+}
+
+func TestChangesFor(t *testing.T) {
+	song := Entry{Era: "Era", Name: "Song"}
+	diff := TrackerDiff{Changes: []EntryChange{
+		{Kind: ChangeQuality, Tab: "Main", Entry: song},
+		{Kind: ChangeLeaked, Tab: "Main", Entry: song},
+		{Kind: ChangeType, Tab: "Main", Entry: song},
+		{Kind: ChangeRemoved, Tab: "Main", Entry: Entry{Era: "Era", Name: "Gone"}},
+		{Kind: ChangeAdded, Tab: "Other", Entry: Entry{Era: "Era", Name: "Elsewhere"}},
+	}}
+	want := map[string]ChangeKind{song.Key(): ChangeLeaked}
+	if got := diff.ChangesFor("Main"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangesFor = %v, want %v", got, want)
+	}
+}
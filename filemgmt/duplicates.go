@@ -0,0 +1,161 @@
+package filemgmt
+
+import (
+	"sort"
+	"strings"
+)
+
+// DuplicateEntry is an entry together with where it was found.
+type DuplicateEntry struct {
+	Tracker string
+	Tab     string
+	Entry   Entry
+}
+
+// DuplicateGroup holds entries that are likely the same song, with the
+// reasons they were grouped ("title", "alt title", "link", "length").
+type DuplicateGroup struct {
+	Reasons []string
+	Entries []DuplicateEntry
+}
+
+// FindDuplicates groups the entries of the given trackers that share a title
+// (base title and version), an alt title or a link. Entries with the same
+// known length whose titles contain one another count as near-duplicates.
+func FindDuplicates(trackers map[string][]Tracker) []DuplicateGroup {
+	var entries []DuplicateEntry
+	names := make([]string, 0, len(trackers))
+	for name := range trackers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, tab := range trackers[name] {
+			for _, entry := range tab.Entries {
+				entries = append(entries, DuplicateEntry{Tracker: name, Tab: tab.Tab, Entry: entry})
+			}
+		}
+	}
+
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	type edge struct {
+		entry  int
+		reason string
+	}
+	var edges []edge
+	union := func(a, b int, reason string) {
+		edges = append(edges, edge{a, reason})
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	seen := make(map[string]int)
+	link := func(i int, key, reason string) {
+		if first, ok := seen[key]; ok {
+			union(first, i, reason)
+			return
+		}
+		seen[key] = i
+	}
+
+	titles := make([]string, len(entries))
+	byLength := make(map[int64][]int)
+	for i, dup := range entries {
+		title := dup.Entry.Title()
+		titles[i] = normalizeHeader(title.Base)
+		if titles[i] != "" {
+			link(i, "name:"+normalizeHeader(title.Base+" "+strings.Join(title.Versions, " ")), "title")
+		}
+		for _, alt := range title.AltTitles {
+			if alt = normalizeHeader(alt); len(alt) > 1 {
+				link(i, "alt:"+alt, "alt title")
+			}
+		}
+		for _, l := range dup.Entry.LinkList() {
+			link(i, "link:"+strings.ToLower(strings.TrimRight(l, "/")), "link")
+		}
+		if length := dup.Entry.ParsedLength(); length.Known {
+			byLength[int64(length.Value)] = append(byLength[int64(length.Value)], i)
+		}
+	}
+
+	// alt titles also match other entries' base titles
+	for i, dup := range entries {
+		for _, alt := range dup.Entry.Title().AltTitles {
+			if j, ok := seen["name:"+normalizeHeader(alt)]; ok && j != i {
+				union(j, i, "alt title")
+			}
+		}
+	}
+
+	for _, group := range byLength {
+		for a := 0; a < len(group); a++ {
+			for b := a + 1; b < len(group); b++ {
+				ta, tb := titles[group[a]], titles[group[b]]
+				if len(ta) > 2 && len(tb) > 2 && (strings.Contains(ta, tb) || strings.Contains(tb, ta)) {
+					union(group[a], group[b], "length")
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range entries {
+		members[find(i)] = append(members[find(i)], i)
+	}
+	groupReasons := make(map[int]map[string]bool)
+	for _, e := range edges {
+		root := find(e.entry)
+		if groupReasons[root] == nil {
+			groupReasons[root] = make(map[string]bool)
+		}
+		groupReasons[root][e.reason] = true
+	}
+
+	var groups []DuplicateGroup
+	for root, indexes := range members {
+		if len(indexes) < 2 {
+			continue
+		}
+		var group DuplicateGroup
+		for _, reason := range []string{"title", "alt title", "link", "length"} {
+			if groupReasons[root][reason] {
+				group.Reasons = append(group.Reasons, reason)
+			}
+		}
+		for _, i := range indexes {
+			group.Entries = append(group.Entries, entries[i])
+		}
+		groups = append(groups, group)
+	}
+	// groups come out of a map, so ties are broken down to the first entry's
+	// position to keep the order, and the cursor, steady between runs
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Entries[0], groups[j].Entries[0]
+		if len(groups[i].Entries) != len(groups[j].Entries) {
+			return len(groups[i].Entries) > len(groups[j].Entries)
+		}
+		if ta, tb := strings.ToLower(a.Entry.Title().Base), strings.ToLower(b.Entry.Title().Base); ta != tb {
+			return ta < tb
+		}
+		if a.Tracker != b.Tracker {
+			return a.Tracker < b.Tracker
+		}
+		if a.Tab != b.Tab {
+			return a.Tab < b.Tab
+		}
+		return a.Entry.Line < b.Entry.Line
+	})
+	return groups
+}
@@ -0,0 +1,71 @@
+package filemgmt
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// groupSummary lists each group as its reasons and "tracker/line" members.
+func groupSummary(groups []DuplicateGroup) []string {
+	var summary []string
+	for _, group := range groups {
+		s := fmt.Sprint(group.Reasons)
+		for _, dup := range group.Entries {
+			s += fmt.Sprintf(" %s/%d", dup.Tracker, dup.Entry.Line)
+		}
+		summary = append(summary, s)
+	}
+	return summary
+}
+
+func TestFindDuplicates(t *testing.T) {
+	trackers := map[string][]Tracker{
+		"a": {{Tab: "Main", Entries: []Entry{
+			{Name: "Song", Line: 1},
+			{Name: "Other Song", Links: "https://host.com/x", Line: 2},
+			{Name: "Long Title Here", TrackLength: "3:21", Line: 3},
+			{Name: "Song [V2]", Line: 4},
+			{Name: "Alone", Line: 5},
+		}}},
+		"b": {{Tab: "Main", Entries: []Entry{
+			{Name: "⭐ song (feat. X)", Line: 1},
+			{Name: "Renamed", Links: "https://host.com/x/", Line: 2},
+			{Name: "Long Title", TrackLength: "3:21", Line: 3},
+			{Name: "Fresh (Song [V2])", Line: 4},
+			{Name: "Tiny", TrackLength: "1:00", Line: 5},
+		}}},
+	}
+
+	want := []string{
+		"[length] a/3 b/3",
+		"[link] a/2 b/2",
+		"[title] a/1 b/1",
+		"[alt title] a/4 b/4",
+	}
+	for range 20 {
+		if got := groupSummary(FindDuplicates(trackers)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("groups =\n%q\nwant\n%q", got, want)
+		}
+	}
+}
+
+func TestFindDuplicatesOrder(t *testing.T) {
+	// equal sized groups with the same title are ordered by where they are
+	trackers := map[string][]Tracker{
+		"a": {{Tab: "Main", Entries: []Entry{
+			{Name: "Song", Links: "https://host.com/1", Line: 1},
+			{Name: "Song", Links: "https://host.com/2", Line: 2},
+		}}},
+		"b": {{Tab: "Main", Entries: []Entry{
+			{Name: "Other", Links: "https://host.com/1", Line: 1},
+			{Name: "Different", Links: "https://host.com/2", Line: 2},
+		}}},
+	}
+	first := groupSummary(FindDuplicates(trackers))
+	for range 20 {
+		if got := groupSummary(FindDuplicates(trackers)); !reflect.DeepEqual(got, first) {
+			t.Fatalf("order changed between runs: %q then %q", first, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package filemgmt
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type EraCount struct {
+	Count int
+	Label string
+}
+
+type Era struct {
+	Name string
+	// Summary is the first cell of the era row, usually the file count breakdown.
+	Summary     string
+	Counts      []EraCount
+	TimeFrame   string
+	Description string
+	Entries     []Entry
+}
+
+var (
+	eraCountRe     = regexp.MustCompile(`(\d+)\s*([^\d,;\n]+)`)
+	eraCountLineRe = regexp.MustCompile(`^\d+\s+\D`)
+	eraTimeFrameRe = regexp.MustCompile(`\(([^()]*\d[^()]*)\)`)
+)
+
+// ParseEraRow turns an era summary row into an Era. The era column holds the
+// counts ("12 OG File(s), 3 Full, 5 Snippet(s)"), the name column holds the
+// era name followed by its time frame in parentheses and sometimes a blurb,
+// and the notes column holds the description.
+func ParseEraRow(row Entry) Era {
+	era := Era{
+		Name:    FormatTitle(strings.SplitN(row.Name, "\n", 2)[0]),
+		Summary: row.Era,
+		Counts:  ParseEraCounts(row.Era),
+	}
+
+	nameCell := row.Name
+	if match := eraTimeFrameRe.FindStringSubmatchIndex(nameCell); match != nil {
+		era.TimeFrame = strings.TrimSpace(nameCell[match[2]:match[3]])
+		nameCell = nameCell[match[1]:]
+	} else if idx := strings.Index(nameCell, "\n"); idx != -1 {
+		nameCell = nameCell[idx:]
+	} else {
+		nameCell = ""
+	}
+
+	var description []string
+	if extra := strings.TrimSpace(nameCell); extra != "" {
+		description = append(description, extra)
+	}
+	if notes := strings.TrimSpace(row.Notes); notes != "" {
+		description = append(description, notes)
+	}
+	era.Description = strings.Join(description, "\n\n")
+
+	return era
+}
+
+// isEraSummary reports whether cell is only a counts line, e.g.
+// "12 OG File(s), 3 Full", as opposed to an era name like "808s & Heartbreak".
+func isEraSummary(cell string) bool {
+	parts := strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ';' || r == '\n' })
+	if len(parts) == 0 {
+		return false
+	}
+	for _, part := range parts {
+		if !eraCountLineRe.MatchString(strings.TrimSpace(part)) {
+			return false
+		}
+	}
+	return true
+}
+
+func ParseEraCounts(summary string) []EraCount {
+	var counts []EraCount
+	for _, match := range eraCountRe.FindAllStringSubmatch(summary, -1) {
+		count, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		label := strings.TrimSpace(match[2])
+		if label == "" {
+			continue
+		}
+		counts = append(counts, EraCount{Count: count, Label: label})
+	}
+	return counts
+}
@@ -0,0 +1,81 @@
+package filemgmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEraCounts(t *testing.T) {
+	tests := []struct {
+		summary string
+		want    []EraCount
+	}{
+		{"12 OG File(s), 3 Full, 5 Snippet(s)", []EraCount{{12, "OG File(s)"}, {3, "Full"}, {5, "Snippet(s)"}}},
+		{"2 OG File(s)\n1 Stem Bounce(s)", []EraCount{{2, "OG File(s)"}, {1, "Stem Bounce(s)"}}},
+		{"1 Tagged; 4 Partial", []EraCount{{1, "Tagged"}, {4, "Partial"}}},
+		{"No counts here", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseEraCounts(tt.summary); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseEraCounts(%q) = %v, want %v", tt.summary, got, tt.want)
+		}
+	}
+}
+
+func TestIsEraSummary(t *testing.T) {
+	tests := []struct {
+		cell string
+		want bool
+	}{
+		{"12 OG File(s), 3 Full", true},
+		{"2 OG File(s)\n1 Snippet(s)\n", true},
+		{"1 Unavailable", true},
+		{"808s & Heartbreak", false},
+		{"Donda 2", false},
+		{"3 OG File(s), Extras", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isEraSummary(tt.cell); got != tt.want {
+			t.Errorf("isEraSummary(%q) = %v, want %v", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestParseEraRow(t *testing.T) {
+	tests := []struct {
+		name string
+		row  Entry
+		want Era
+	}{
+		{
+			name: "time frame and blurb",
+			row:  Entry{Era: "3 OG File(s)", Name: "Era Name (2018 - 2019)\nRecorded in Wyoming", Notes: "Scrapped."},
+			want: Era{
+				Name:        "Era Name",
+				Summary:     "3 OG File(s)",
+				Counts:      []EraCount{{3, "OG File(s)"}},
+				TimeFrame:   "2018 - 2019",
+				Description: "Recorded in Wyoming\n\nScrapped.",
+			},
+		},
+		{
+			name: "parentheses without a date aren't a time frame",
+			row:  Entry{Era: "1 Full", Name: "Era (Deluxe)"},
+			want: Era{Name: "Era", Summary: "1 Full", Counts: []EraCount{{1, "Full"}}},
+		},
+		{
+			name: "blurb on the next line",
+			row:  Entry{Era: "1 Full", Name: "Era\nSome blurb"},
+			want: Era{Name: "Era", Summary: "1 Full", Counts: []EraCount{{1, "Full"}}, Description: "Some blurb"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseEraRow(tt.row); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEraRow = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,11 @@
 package filemgmt
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"tracker-tui/styles"
 
@@ -38,17 +37,53 @@ type Theme struct {
 }
 type item struct {
 	fileName, dateOfCreation string
+	displayName, artist      string
+	metadata                 []string
+	warnings                 int
+	tabs                     int
+	snapshots                int
 }
 
-func (i item) Title() string       { return i.fileName }
-func (i item) Description() string { return i.dateOfCreation }
-func (i item) FilterValue() string { return i.fileName }
+func (i item) Title() string {
+	title := i.fileName
+	if i.displayName != "" {
+		title = i.displayName
+	}
+	if i.warnings > 0 {
+		return "⚠ " + title
+	}
+	return title
+}
+func (i item) Description() string {
+	description := i.dateOfCreation
+	if i.artist != "" {
+		description = i.artist
+	}
+	if i.tabs > 1 {
+		description += fmt.Sprintf(" · %d tabs", i.tabs)
+	}
+	if i.snapshots > 1 {
+		description += fmt.Sprintf(" · %d snapshots", i.snapshots)
+	}
+	if i.warnings > 0 {
+		description += fmt.Sprintf(" · %d parse warnings", i.warnings)
+	}
+	if len(i.metadata) > 0 {
+		description += " · " + strings.Join(i.metadata, " · ")
+	}
+	return description
+}
+func (i item) FilterValue() string {
+	return strings.Join([]string{i.displayName, i.artist, i.fileName}, " ")
+}
 
-func ReturnListOfFiles() ([]list.Item, error) {
-	var items []list.Item
+// Name is the tracker's directory (or file) name under TrackersDir.
+func (i item) Name() string { return i.fileName }
 
-	homeDir, _ := os.UserHomeDir()
-	downloadDir := filepath.Join(homeDir, "Documents", "tracker-tui", "csv")
+// TrackerNames lists the trackers in TrackersDir, skipping hidden files such
+// as unfinished imports.
+func TrackerNames() ([]string, error) {
+	downloadDir := TrackersDir()
 	err := os.MkdirAll(downloadDir, os.ModePerm)
 	if err != nil {
 		return nil, err
@@ -59,103 +94,199 @@ func ReturnListOfFiles() ([]list.Item, error) {
 		return nil, err
 	}
 
+	var names []string
 	for _, file := range directory {
-		info, err := file.Info()
+		if !strings.HasPrefix(file.Name(), ".") {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+func ReturnListOfFiles() ([]list.Item, error) {
+	var items []list.Item
+
+	names, err := TrackerNames()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(TrackersDir(), name))
 		if err != nil {
 			continue
 		}
 		modTime := info.ModTime().Format("2006/01/02")
+		tabs, err := LoadTracker(name)
+		if err != nil {
+			continue
+		}
 
-		items = append(items, item{
-			fileName:       file.Name(),
+		snapshots, _ := Snapshots(name)
+		if len(snapshots) > 0 {
+			modTime = snapshots[0].Time.Format("2006/01/02")
+		}
+
+		trackerInfo, _ := ReadTrackerInfo(name)
+		listItem := item{
+			fileName:       name,
 			dateOfCreation: modTime,
-		})
+			displayName:    trackerInfo.DisplayName,
+			artist:         trackerInfo.Artist,
+			metadata:       tabs[0].Metadata,
+			tabs:           len(tabs),
+			snapshots:      len(snapshots),
+		}
+		for _, tab := range tabs {
+			listItem.warnings += len(tab.Warnings)
+		}
+		items = append(items, listItem)
 	}
 
 	return items, nil
 }
 
-func ReadCSVFile(filename string) ([]table.Column, []table.Row, error) {
-	var columns []table.Column
-	var rows []table.Row
-	f, err := os.Open(filename)
+type snapshotItem struct {
+	snapshot Snapshot
+	current  bool
+	tabs     int
+	changes  int
+	refresh  bool
+}
+
+func (i snapshotItem) Title() string {
+	title := i.snapshot.Time.Format("2006/01/02 15:04:05")
+	if i.current {
+		title += " (current)"
+	}
+	return title
+}
+func (i snapshotItem) Description() string {
+	description := fmt.Sprintf("%d tabs", i.tabs)
+	if i.refresh {
+		description += fmt.Sprintf(" · %d changes", i.changes)
+	}
+	return description
+}
+func (i snapshotItem) FilterValue() string { return i.snapshot.ID }
+
+// Current reports whether the snapshot is the tracker's newest.
+func (i snapshotItem) Current() bool { return i.current }
+
+func ReturnListOfSnapshots(name string) ([]list.Item, error) {
+	var items []list.Item
+
+	snapshots, err := Snapshots(name)
 	if err != nil {
-		return nil, nil, err
-	}
-	reader := csv.NewReader(f)
-	var row int = 0
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			fmt.Println("Error reading CSV data:", err)
-			break
-		}
+		return nil, err
+	}
 
-		if row == 0 {
-			for i := range record {
-				columns = append(columns, table.Column{Title: record[i], Width: returnProperLength(record[i], i)})
-			}
-		} else {
-			var subRow table.Row
-			for i := range record {
-				subRow = append(subRow, record[i])
-			}
-			rows = append(rows, subRow)
+	for i, snapshot := range snapshots {
+		listItem := snapshotItem{snapshot: snapshot, current: i == 0}
+		if manifest, err := ReadManifest(snapshot.Dir); err == nil {
+			listItem.tabs = len(manifest.Tabs)
+		}
+		if changes, err := ReadChanges(snapshot.Dir); err == nil {
+			listItem.refresh = true
+			listItem.changes = len(changes.Changes)
 		}
+		items = append(items, listItem)
+	}
+
+	return items, nil
+}
 
-		fmt.Println(record)
-		row++
+func ReadCSVFile(filename string) (Tracker, error) {
+	return readCSVFile(filename, nil)
+}
+
+func readCSVFile(filename string, mapping ColumnMapping) (Tracker, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Tracker{}, err
 	}
+	defer f.Close()
 
-	return columns, rows, nil
+	records, lines, warnings, err := ReadRecords(f)
+	if err != nil {
+		return Tracker{}, fmt.Errorf("reading %s: %w", filepath.Base(filename), err)
+	}
+
+	tracker := parseTracker(records, lines, mapping)
+	tracker.Warnings = append(warnings, tracker.Warnings...)
+	sort.SliceStable(tracker.Warnings, func(i, j int) bool {
+		return tracker.Warnings[i].Line < tracker.Warnings[j].Line
+	})
+	return tracker, nil
 }
 
-func GenerateMainTable(largeColumns []table.Column, largeRows []table.Row) ([]table.Column, []table.Row, error) {
+func GenerateMainTable(tracker Tracker) ([]table.Column, []table.Row, error) {
 	var columns []table.Column
 	var rows []table.Row
 
 	columns = append(columns, table.Column{Title: "Files in Era", Width: 25})
 	columns = append(columns, table.Column{Title: "Name of Era", Width: 35})
 
-	for i := range largeRows {
-		if largeRows[i][len(largeRows[i])-2] == "" && len(largeRows[i][1]) > 1 && len(largeRows[i][0]) > 1 {
-			if len(largeRows[i]) >= 2 {
-				rows = append(rows, table.Row{
-					largeRows[i][0],
-					FormatTitle(largeRows[i][1]),
-				})
-			}
-		}
+	for _, era := range tracker.Eras {
+		rows = append(rows, table.Row{
+			era.Summary,
+			era.Name,
+		})
 	}
 
 	return columns, rows, nil
 }
 
-func GenerateEraTable(largeColumns []table.Column, largeRows []table.Row, matchString string) ([]table.Column, []table.Row, error) {
+var eraTableFields = []Field{FieldName, FieldNotes, FieldTrackLength, FieldFileDate, FieldLeakDate, FieldType, FieldQuality, FieldLinks}
+
+func GenerateEraTable(tracker Tracker, entries []Entry) ([]table.Column, []table.Row, error) {
 	var columns []table.Column
 	var rows []table.Row
-	caseUpper := strings.ToUpper(FormatTitle(matchString))
 
-	if len(largeColumns) > 1 {
-		columns = append(columns, largeColumns[1:]...)
+	var fields []Field
+	for _, f := range eraTableFields {
+		if tracker.Has(f) {
+			fields = append(fields, f)
+			header := tracker.Header(f)
+			columns = append(columns, table.Column{Title: header, Width: returnProperLength(header, f)})
+			if f == FieldName {
+				columns = append(columns,
+					table.Column{Title: "Ver.", Width: 4},
+					table.Column{Title: "Feat.", Width: 12},
+					table.Column{Title: "Prod.", Width: 12},
+				)
+			}
+		}
 	}
 
-	for i := range largeRows {
-		if strings.ToUpper(largeRows[i][0]) == caseUpper {
-			if len(largeRows[i]) > 1 {
-				rows = append(rows, largeRows[i][1:])
+	for _, entry := range entries {
+		var row table.Row
+		for _, f := range fields {
+			if f != FieldName {
+				row = append(row, entry.Value(f))
+				continue
+			}
+			title := entry.Title()
+			name := title.Base
+			if badges := title.Badges(); badges != "" {
+				name = badges + " " + name
 			}
+			row = append(row,
+				name,
+				strings.Join(title.Versions, ","),
+				strings.Join(title.Features, ", "),
+				strings.Join(title.Producers, ", "),
+			)
 		}
+		rows = append(rows, row)
 	}
 
 	return columns, rows, nil
 }
 
-func returnProperLength(record string, column int) int {
-	if column == 0 || column == 1 {
-		return 15 + 12*column // this is my favourite line out of all the files :3
+func returnProperLength(record string, field Field) int {
+	if field == FieldEra || field == FieldName {
+		return 15 + 12*int(field) // this is my favourite line out of all the files :3
 	}
 
 	if idx := strings.Index(record, "("); idx != -1 {
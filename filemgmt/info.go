@@ -0,0 +1,134 @@
+package filemgmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const infoName = "info.json"
+
+// ColumnMapping maps field names ("Name", "Link(s)"...) to the header of the
+// column they are read from.
+type ColumnMapping map[string]string
+
+// TrackerInfo is the sidecar kept for every tracker: where it came from,
+// what to call it and how its columns are read.
+type TrackerInfo struct {
+	DisplayName   string
+	Artist        string
+	Source        string
+	SpreadsheetID string
+	GIDs          []string
+	Imported      time.Time
+	Refreshed     time.Time
+	// Columns holds each tab's column mapping. It is recorded from the
+	// detected headers on import and can be edited to fix a tracker whose
+	// columns aren't recognised.
+	Columns map[string]ColumnMapping
+}
+
+func (i TrackerInfo) Title(name string) string {
+	if i.DisplayName != "" {
+		return i.DisplayName
+	}
+	return name
+}
+
+// sidecarPath is where a tracker keeps one of its own files. Trackers
+// imported as a single CSV keep them as hidden files next to it.
+func sidecarPath(name string, file string) string {
+	path := filepath.Join(TrackersDir(), name)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return filepath.Join(TrackersDir(), "."+name+"."+file)
+	}
+	return filepath.Join(path, file)
+}
+
+func ReadTrackerInfo(name string) (TrackerInfo, error) {
+	var info TrackerInfo
+	data, err := os.ReadFile(sidecarPath(name, infoName))
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, fmt.Errorf("invalid tracker info: %w", err)
+	}
+	return info, nil
+}
+
+func WriteTrackerInfo(name string, info TrackerInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sidecarPath(name, infoName), data, 0644)
+}
+
+// TrackerForSpreadsheet finds the tracker imported from a spreadsheet, so
+// sheets that share a title don't share a history.
+func TrackerForSpreadsheet(id string) (string, bool) {
+	names, err := TrackerNames()
+	if err != nil || id == "" {
+		return "", false
+	}
+	for _, name := range names {
+		if info, err := ReadTrackerInfo(name); err == nil && info.SpreadsheetID != "" {
+			if info.SpreadsheetID == id {
+				return name, true
+			}
+			continue
+		}
+		// imported before info was kept
+		dir, err := CurrentSnapshotDir(name)
+		if err != nil {
+			continue
+		}
+		if manifest, err := ReadManifest(dir); err == nil && manifest.SpreadsheetID == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (t Tracker) ColumnMapping() ColumnMapping {
+	mapping := make(ColumnMapping)
+	for f, col := range t.Columns {
+		if col < len(t.Headers) {
+			mapping[f.String()] = t.Headers[col]
+		}
+	}
+	return mapping
+}
+
+func fieldByName(name string) (Field, bool) {
+	for f := Field(0); f < fieldCount; f++ {
+		if fieldNames[f] == name {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// apply overrides the detected columns. A field mapped to an empty header is
+// dropped, headers that aren't in the sheet are ignored.
+func (mapping ColumnMapping) apply(headers []string, columns map[Field]int) {
+	for name, header := range mapping {
+		f, ok := fieldByName(name)
+		if !ok {
+			continue
+		}
+		if header == "" {
+			delete(columns, f)
+			continue
+		}
+		for i := range headers {
+			if normalizeHeader(headers[i]) == normalizeHeader(header) {
+				columns[f] = i
+				break
+			}
+		}
+	}
+}
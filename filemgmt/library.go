@@ -0,0 +1,228 @@
+package filemgmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const libraryName = "library.json"
+
+type LibraryArtist struct {
+	Name     string
+	Trackers []string
+}
+
+type LibraryEra struct {
+	Artist    string
+	Tracker   string
+	Tab       string
+	Name      string
+	TimeFrame string
+	Entries   int
+}
+
+type LibraryEntry struct {
+	Artist  string
+	Tracker string
+	Tab     string
+	Entry   Entry
+}
+
+// Library indexes the artists, eras and entries of every imported tracker so
+// they can be searched and browsed without opening each tracker.
+type Library struct {
+	Updated time.Time
+	Artists []LibraryArtist
+	Eras    []LibraryEra
+	Entries []LibraryEntry
+}
+
+type LibraryResult struct {
+	LibraryEntry
+	Matched        string
+	MatchedIndexes []int
+	Score          int
+}
+
+func libraryPath() string {
+	return filepath.Join(filepath.Dir(TrackersDir()), libraryName)
+}
+
+func ReadLibrary() (Library, error) {
+	var lib Library
+	data, err := os.ReadFile(libraryPath())
+	if err != nil {
+		return lib, err
+	}
+	if err := json.Unmarshal(data, &lib); err != nil {
+		return lib, fmt.Errorf("invalid library index: %w", err)
+	}
+	return lib, nil
+}
+
+func WriteLibrary(lib Library) error {
+	lib.Updated = time.Now()
+	data, err := json.Marshal(lib)
+	if err != nil {
+		return err
+	}
+	tmp := libraryPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, libraryPath())
+}
+
+// LoadLibrary reads the index, building it from the trackers on disk the
+// first time.
+func LoadLibrary() (Library, error) {
+	lib, err := ReadLibrary()
+	if os.IsNotExist(err) {
+		return RebuildLibrary()
+	}
+	return lib, err
+}
+
+func RebuildLibrary() (Library, error) {
+	var lib Library
+	names, err := TrackerNames()
+	if err != nil {
+		return lib, err
+	}
+	for _, name := range names {
+		if tabs, err := LoadTracker(name); err == nil {
+			lib = lib.withTracker(name, tabs)
+		}
+	}
+	return lib, WriteLibrary(lib)
+}
+
+// IndexTracker replaces a tracker's records in the library with its newest
+// version, or drops them when the tracker no longer exists.
+func IndexTracker(name string) error {
+	lib, err := ReadLibrary()
+	if os.IsNotExist(err) {
+		_, err = RebuildLibrary()
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	lib = lib.withoutTracker(name)
+	if tabs, err := LoadTracker(name); err == nil {
+		lib = lib.withTracker(name, tabs)
+	}
+	return WriteLibrary(lib)
+}
+
+func (l Library) withoutTracker(name string) Library {
+	var kept Library
+	kept.Updated = l.Updated
+	for _, artist := range l.Artists {
+		var trackers []string
+		for _, tracker := range artist.Trackers {
+			if tracker != name {
+				trackers = append(trackers, tracker)
+			}
+		}
+		if len(trackers) > 0 {
+			kept.Artists = append(kept.Artists, LibraryArtist{Name: artist.Name, Trackers: trackers})
+		}
+	}
+	for _, era := range l.Eras {
+		if era.Tracker != name {
+			kept.Eras = append(kept.Eras, era)
+		}
+	}
+	for _, entry := range l.Entries {
+		if entry.Tracker != name {
+			kept.Entries = append(kept.Entries, entry)
+		}
+	}
+	return kept
+}
+
+func (l Library) withTracker(name string, tabs []Tracker) Library {
+	info, _ := ReadTrackerInfo(name)
+	artist := info.Artist
+	if artist == "" {
+		artist = info.Title(strings.TrimSuffix(name, filepath.Ext(name)))
+	}
+
+	found := false
+	for i := range l.Artists {
+		if strings.EqualFold(l.Artists[i].Name, artist) {
+			l.Artists[i].Trackers = append(l.Artists[i].Trackers, name)
+			found = true
+		}
+	}
+	if !found {
+		l.Artists = append(l.Artists, LibraryArtist{Name: artist, Trackers: []string{name}})
+	}
+	sort.Slice(l.Artists, func(i, j int) bool {
+		return strings.ToLower(l.Artists[i].Name) < strings.ToLower(l.Artists[j].Name)
+	})
+
+	for _, tab := range tabs {
+		for _, era := range tab.Eras {
+			l.Eras = append(l.Eras, LibraryEra{
+				Artist:    artist,
+				Tracker:   name,
+				Tab:       tab.Tab,
+				Name:      era.Name,
+				TimeFrame: era.TimeFrame,
+				Entries:   len(era.Entries),
+			})
+		}
+		for _, entry := range tab.Entries {
+			l.Entries = append(l.Entries, LibraryEntry{Artist: artist, Tracker: name, Tab: tab.Tab, Entry: entry})
+		}
+	}
+	return l
+}
+
+// ErasOf lists an artist's eras across all of their trackers.
+func (l Library) ErasOf(artist string) []LibraryEra {
+	var eras []LibraryEra
+	for _, era := range l.Eras {
+		if strings.EqualFold(era.Artist, artist) {
+			eras = append(eras, era)
+		}
+	}
+	return eras
+}
+
+func (l Library) EntriesOf(era LibraryEra) []LibraryEntry {
+	var entries []LibraryEntry
+	for _, entry := range l.Entries {
+		if entry.Tracker == era.Tracker && entry.Tab == era.Tab && strings.EqualFold(FormatTitle(entry.Entry.Era), era.Name) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// SearchLibrary fuzzy matches the query across every indexed entry.
+func SearchLibrary(l Library, query string) []LibraryResult {
+	entries := make([]Entry, len(l.Entries))
+	for i, entry := range l.Entries {
+		entries[i] = entry.Entry
+	}
+
+	var results []LibraryResult
+	for _, result := range searchEntries(entries, query) {
+		results = append(results, LibraryResult{
+			LibraryEntry:   l.Entries[result.Index],
+			Matched:        result.Matched,
+			MatchedIndexes: result.MatchedIndexes,
+			Score:          result.Score,
+		})
+	}
+	return results
+}
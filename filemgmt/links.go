@@ -0,0 +1,28 @@
+package filemgmt
+
+import (
+	"regexp"
+	"strings"
+)
+
+var linkRe = regexp.MustCompile(`https?://[^\s,;"<>]+`)
+
+// ParseLinks pulls every URL out of a links cell. Cells can hold mirrors,
+// alternate versions or a mix of hosts separated by newlines or spaces.
+func ParseLinks(cell string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, link := range linkRe.FindAllString(cell, -1) {
+		link = strings.TrimRight(link, ".)]")
+		if seen[link] {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+	}
+	return links
+}
+
+func (e Entry) LinkList() []string {
+	return ParseLinks(e.Links)
+}
@@ -0,0 +1,30 @@
+package filemgmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLinks(t *testing.T) {
+	tests := []struct {
+		cell string
+		want []string
+	}{
+		{"https://pillowcase.su/f/abc", []string{"https://pillowcase.su/f/abc"}},
+		{
+			"https://a.com/1\nhttps://b.com/2 https://c.com/3",
+			[]string{"https://a.com/1", "https://b.com/2", "https://c.com/3"},
+		},
+		{"https://a.com/1, https://b.com/2;https://c.com/3", []string{"https://a.com/1", "https://b.com/2", "https://c.com/3"}},
+		{"mirror (https://a.com/song.mp3).", []string{"https://a.com/song.mp3"}},
+		{"https://a.com/1\nhttps://a.com/1", []string{"https://a.com/1"}},
+		{"http://old.host/x", []string{"http://old.host/x"}},
+		{"Link: N/A", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseLinks(tt.cell); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLinks(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
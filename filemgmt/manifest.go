@@ -0,0 +1,101 @@
+package filemgmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const manifestName = "tracker.json"
+
+type Tab struct {
+	Name string
+	GID  string
+	File string
+}
+
+// Manifest sits next to the tab CSVs of an imported tracker.
+type Manifest struct {
+	Source        string
+	SpreadsheetID string
+	Tabs          []Tab
+}
+
+func TrackersDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Documents", "tracker-tui", "csv")
+}
+
+func ReadManifest(dir string) (Manifest, error) {
+	var manifest Manifest
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid tracker manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+func WriteManifest(dir string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifestName), data, 0644)
+}
+
+// LoadTracker reads every tab of the newest snapshot of a tracker in the csv
+// directory. Trackers imported before tabs were supported are single CSV
+// files and come back as one tab.
+func LoadTracker(name string) ([]Tracker, error) {
+	path := filepath.Join(TrackersDir(), name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	trackerInfo, _ := ReadTrackerInfo(name)
+
+	if !info.IsDir() {
+		tab := strings.TrimSuffix(name, filepath.Ext(name))
+		tracker, err := readCSVFile(path, trackerInfo.Columns[tab])
+		if err != nil {
+			return nil, err
+		}
+		tracker.Tab = tab
+		return []Tracker{tracker}, nil
+	}
+
+	dir, err := CurrentSnapshotDir(name)
+	if err != nil {
+		return nil, err
+	}
+	return LoadTabs(dir, trackerInfo.Columns)
+}
+
+// LoadTabs reads the tabs listed in the manifest of a snapshot directory,
+// applying any column mapping recorded for them.
+func LoadTabs(dir string, columns map[string]ColumnMapping) ([]Tracker, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var tabs []Tracker
+	for _, tab := range manifest.Tabs {
+		tracker, err := readCSVFile(filepath.Join(dir, tab.File), columns[tab.Name])
+		if err != nil {
+			return nil, err
+		}
+		tracker.Tab = tab.Name
+		tabs = append(tabs, tracker)
+	}
+	if len(tabs) == 0 {
+		return nil, fmt.Errorf("tracker %s has no tabs", filepath.Base(dir))
+	}
+	return tabs, nil
+}
@@ -0,0 +1,85 @@
+package filemgmt
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+)
+
+type SearchResult struct {
+	Entry Entry
+	// Index is the position of the entry in the searched slice.
+	Index int
+	// Matched is the text the query matched against: the title, an alt
+	// title, a feature or the notes.
+	Matched        string
+	MatchedIndexes []int
+	Score          int
+}
+
+type searchTarget struct {
+	entry int
+	text  string
+}
+
+type searchTargets []searchTarget
+
+func (s searchTargets) String(i int) string { return s[i].text }
+func (s searchTargets) Len() int            { return len(s) }
+
+// SearchEntries fuzzy matches the query across every entry of the tracker,
+// keeping the best match per entry.
+func SearchEntries(t Tracker, query string) []SearchResult {
+	return searchEntries(t.Entries, query)
+}
+
+func searchEntries(entries []Entry, query string) []SearchResult {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	var targets searchTargets
+	for i, entry := range entries {
+		title := entry.Title()
+		texts := []string{title.Base}
+		texts = append(texts, title.AltTitles...)
+		texts = append(texts, title.Features...)
+		if notes := strings.Join(strings.Fields(entry.Notes), " "); notes != "" {
+			texts = append(texts, notes)
+		}
+		for _, text := range texts {
+			if text != "" {
+				targets = append(targets, searchTarget{entry: i, text: text})
+			}
+		}
+	}
+
+	best := make(map[int]SearchResult)
+	for _, match := range fuzzy.FindFrom(query, targets) {
+		target := targets[match.Index]
+		if existing, ok := best[target.entry]; ok && existing.Score >= match.Score {
+			continue
+		}
+		best[target.entry] = SearchResult{
+			Entry:          entries[target.entry],
+			Index:          target.entry,
+			Matched:        target.text,
+			MatchedIndexes: match.MatchedIndexes,
+			Score:          match.Score,
+		}
+	}
+
+	results := make([]SearchResult, 0, len(best))
+	for _, result := range best {
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Index < results[j].Index
+	})
+	return results
+}
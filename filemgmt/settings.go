@@ -0,0 +1,60 @@
+package filemgmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type Settings struct {
+	// SnapshotsToKeep caps how many snapshots a tracker keeps, 0 keeps all.
+	SnapshotsToKeep int
+	// SnapshotMaxAgeDays prunes snapshots older than this, 0 keeps all.
+	// The newest snapshot is never pruned.
+	SnapshotMaxAgeDays int
+	// CacheMaxMB caps the size of the song cache, the least recently played
+	// songs that aren't pinned are evicted past it. 0 keeps everything.
+	CacheMaxMB int
+}
+
+var CurrentSettings = Settings{
+	SnapshotsToKeep:    10,
+	SnapshotMaxAgeDays: 180,
+	CacheMaxMB:         2048,
+}
+
+func InitSettings() error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("could not determine home directory: %w", err)
+	}
+
+	configDir := filepath.Join(homeDir, "Documents", "tracker-tui")
+	settingsPath := filepath.Join(configDir, "settings.json")
+
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
+		data, _ := json.MarshalIndent(CurrentSettings, "", "  ")
+		if err := os.WriteFile(settingsPath, data, 0644); err != nil {
+			return fmt.Errorf("could not write default settings: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return fmt.Errorf("could not read settings: %w", err)
+	}
+
+	// fields missing from older settings files keep their defaults
+	settings := CurrentSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	CurrentSettings = settings
+
+	return nil
+}
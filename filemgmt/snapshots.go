@@ -0,0 +1,104 @@
+package filemgmt
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	snapshotsDir = "snapshots"
+	// milliseconds keep an import and a refresh in the same second apart
+	snapshotIDLayout = "20060102-150405.000"
+	// parsing accepts the fraction when it's there, so snapshots taken before
+	// it was added still load
+	snapshotIDParseLayout = "20060102-150405"
+)
+
+type Snapshot struct {
+	ID   string
+	Time time.Time
+	Dir  string
+}
+
+func NewSnapshotID(t time.Time) string {
+	return t.Format(snapshotIDLayout)
+}
+
+func SnapshotDir(name string, id string) string {
+	return filepath.Join(TrackersDir(), name, snapshotsDir, id)
+}
+
+// Snapshots lists every snapshot of a tracker, newest first.
+func Snapshots(name string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(TrackersDir(), name, snapshotsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		t, err := time.ParseInLocation(snapshotIDParseLayout, entry.Name(), time.Local)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{ID: entry.Name(), Time: t, Dir: SnapshotDir(name, entry.Name())})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.After(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// CurrentSnapshotDir returns the directory holding the newest version of a
+// tracker. Trackers imported before snapshots existed keep their tabs
+// directly in the tracker directory.
+func CurrentSnapshotDir(name string) (string, error) {
+	snapshots, err := Snapshots(name)
+	if err != nil {
+		return "", err
+	}
+	if len(snapshots) > 0 {
+		return snapshots[0].Dir, nil
+	}
+	return filepath.Join(TrackersDir(), name), nil
+}
+
+func LoadSnapshot(name string, id string) ([]Tracker, error) {
+	info, _ := ReadTrackerInfo(name)
+	return LoadTabs(SnapshotDir(name, id), info.Columns)
+}
+
+// PruneSnapshots removes the snapshots the retention settings no longer
+// cover and returns their ids. The newest snapshot is always kept.
+func PruneSnapshots(name string, settings Settings) ([]string, error) {
+	snapshots, err := Snapshots(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	cutoff := time.Now().AddDate(0, 0, -settings.SnapshotMaxAgeDays)
+	for i, snapshot := range snapshots {
+		if i == 0 {
+			continue
+		}
+		tooMany := settings.SnapshotsToKeep > 0 && i >= settings.SnapshotsToKeep
+		tooOld := settings.SnapshotMaxAgeDays > 0 && snapshot.Time.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.RemoveAll(snapshot.Dir); err != nil {
+			return removed, err
+		}
+		removed = append(removed, snapshot.ID)
+	}
+	return removed, nil
+}
@@ -0,0 +1,141 @@
+package filemgmt
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Count struct {
+	Label string
+	Count int
+}
+
+type TrackerStats struct {
+	Entries      int
+	Eras         []Count
+	Types        []Count
+	Qualities    []Count
+	Hosts        []Count
+	TotalLength  time.Duration
+	KnownLengths int
+	Downloaded   int
+}
+
+func (s TrackerStats) AverageLength() time.Duration {
+	if s.KnownLengths == 0 {
+		return 0
+	}
+	return s.TotalLength / time.Duration(s.KnownLengths)
+}
+
+func SongsDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Documents", "tracker-tui", "songs")
+}
+
+// DownloadedSongs returns the normalized names of the songs downloaded so far.
+func DownloadedSongs() (map[string]bool, error) {
+	songs := make(map[string]bool)
+	files, err := os.ReadDir(SongsDir())
+	if os.IsNotExist(err) {
+		return songs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || strings.HasSuffix(file.Name(), ".tmp") || file.Name() == songCacheName {
+			continue
+		}
+		songs[normalizeHeader(strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())))] = true
+	}
+	// cached songs are named by hash, the index knows their titles
+	cache, err := ReadSongCache()
+	if err != nil {
+		return songs, nil
+	}
+	for _, song := range cache {
+		songs[normalizeHeader(song.Title)] = true
+	}
+	return songs, nil
+}
+
+// Downloaded guesses from the file name whether an entry was downloaded,
+// downloads are named after the song so that is all there is to go on.
+func (e Entry) Downloaded(songs map[string]bool) bool {
+	for _, name := range []string{FormatTitle(e.Name), e.Title().Base} {
+		if name = normalizeHeader(name); name != "" && songs[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeStats counts the tracker's entries per era, type, quality and link
+// host, and adds up their track lengths.
+func ComputeStats(t Tracker, songs map[string]bool) TrackerStats {
+	s := TrackerStats{Entries: len(t.Entries)}
+	types, qualities, hosts := newCounter(), newCounter(), newCounter()
+
+	for _, era := range t.Eras {
+		s.Eras = append(s.Eras, Count{Label: era.Name, Count: len(era.Entries)})
+	}
+	for _, entry := range t.Entries {
+		types.add(entry.Type)
+		qualities.add(entry.Quality)
+		for _, link := range entry.LinkList() {
+			if parsed, err := url.Parse(link); err == nil && parsed.Host != "" {
+				hosts.add(strings.TrimPrefix(parsed.Host, "www."))
+			}
+		}
+		if length := entry.ParsedLength(); length.Known {
+			s.TotalLength += length.Value
+			s.KnownLengths++
+		}
+		if entry.Downloaded(songs) {
+			s.Downloaded++
+		}
+	}
+
+	s.Types, s.Qualities, s.Hosts = types.sorted(), qualities.sorted(), hosts.sorted()
+	return s
+}
+
+// counter groups values case-insensitively, keeping the first spelling seen
+type counter struct {
+	counts map[string]*Count
+	order  []string
+}
+
+func newCounter() *counter {
+	return &counter{counts: make(map[string]*Count)}
+}
+
+func (c *counter) add(value string) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		value = "(none)"
+	}
+	key := strings.ToLower(value)
+	if count, ok := c.counts[key]; ok {
+		count.Count++
+		return
+	}
+	c.counts[key] = &Count{Label: value, Count: 1}
+	c.order = append(c.order, key)
+}
+
+func (c *counter) sorted() []Count {
+	var counts []Count
+	for _, key := range c.order {
+		counts = append(counts, *c.counts[key])
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+	return counts
+}
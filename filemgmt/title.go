@@ -0,0 +1,178 @@
+package filemgmt
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Marker struct {
+	Emoji string
+	Name  string
+}
+
+// status markers trackers put in front of or after song titles
+var Markers = []Marker{
+	{"⭐", "best"},
+	{"✨", "special"},
+	{"🏆", "grail"},
+	{"🥇", "wanted"},
+	{"🗑️", "worst"},
+	{"🗑", "worst"},
+	{"🤖", "ai"},
+	{"⁉️", "unconfirmed"},
+	{"🔥", "hot"},
+}
+
+type Title struct {
+	Raw       string
+	Base      string
+	AltTitles []string
+	Features  []string
+	Producers []string
+	Versions  []string
+	Markers   []Marker
+}
+
+var (
+	titleGroupRe    = regexp.MustCompile(`\(([^()]*)\)|\[([^\[\]]*)\]`)
+	featureRe       = regexp.MustCompile(`(?i)^(feat\.?|ft\.?|featuring|with)\s+`)
+	producerRe      = regexp.MustCompile(`(?i)^(prod\.?|produced)\s*(by\s+)?`)
+	versionRe       = regexp.MustCompile(`(?i)^(v\d+(\.\d+)?|version\s+\S+|\S+\s+version|og|demo|ref(erence)?|remix|edit|mix\s*\d*)$`)
+	creditSplitRe   = regexp.MustCompile(`\s*(,|&|\band\b|\bx\b)\s*`)
+	altTitleSplitRe = regexp.MustCompile(`\s*[,/]\s*`)
+)
+
+// ParseTitle splits a tracker song title into its parts, e.g.
+// "⭐ Song [V2] (Other Name) (feat. A & B) (prod. C)".
+func ParseTitle(raw string) Title {
+	t := Title{Raw: raw}
+
+	rest := raw
+	for _, marker := range Markers {
+		if strings.Contains(rest, marker.Emoji) {
+			rest = strings.ReplaceAll(rest, marker.Emoji, " ")
+			if !t.HasMarker(marker.Name) {
+				t.Markers = append(t.Markers, marker)
+			}
+		}
+	}
+
+	for _, match := range titleGroupRe.FindAllStringSubmatch(rest, -1) {
+		group := strings.TrimSpace(match[1] + match[2])
+		switch {
+		case group == "":
+		case featureRe.MatchString(group):
+			t.Features = append(t.Features, splitCredits(featureRe.ReplaceAllString(group, ""))...)
+		case producerRe.MatchString(group):
+			t.Producers = append(t.Producers, splitCredits(producerRe.ReplaceAllString(group, ""))...)
+		case versionRe.MatchString(group):
+			t.Versions = append(t.Versions, group)
+		default:
+			for _, alt := range altTitleSplitRe.Split(group, -1) {
+				if alt = strings.TrimSpace(alt); alt != "" {
+					t.AltTitles = append(t.AltTitles, alt)
+				}
+			}
+		}
+	}
+
+	t.Base = strings.Join(strings.Fields(titleGroupRe.ReplaceAllString(rest, " ")), " ")
+	return t
+}
+
+func splitCredits(credits string) []string {
+	var names []string
+	for _, name := range creditSplitRe.Split(credits, -1) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (t Title) HasMarker(name string) bool {
+	for _, marker := range t.Markers {
+		if marker.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Title) Badges() string {
+	var badges []string
+	for _, marker := range t.Markers {
+		badges = append(badges, marker.Emoji)
+	}
+	return strings.Join(badges, "")
+}
+
+func (e Entry) Title() Title {
+	return ParseTitle(e.Name)
+}
+
+// MatchesFilter checks an entry against a filter query. Each word of the
+// query has to match; "feat:", "prod:", "ver:", "alt:" and "tag:" restrict a
+// word to that part of the title, anything else is looked up in the title,
+// alt titles and credits.
+func MatchesFilter(e Entry, query string) bool {
+	title := e.Title()
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		key, value, found := strings.Cut(word, ":")
+		if !found {
+			key, value = "", word
+		}
+
+		var haystack []string
+		switch key {
+		case "feat", "ft":
+			haystack = title.Features
+		case "prod":
+			haystack = title.Producers
+		case "ver", "v":
+			haystack = title.Versions
+		case "alt":
+			haystack = title.AltTitles
+		case "tag":
+			for _, marker := range title.Markers {
+				haystack = append(haystack, marker.Name, marker.Emoji)
+			}
+		default:
+			haystack = append(haystack, title.Base)
+			haystack = append(haystack, title.AltTitles...)
+			haystack = append(haystack, title.Features...)
+			haystack = append(haystack, title.Producers...)
+			haystack = append(haystack, title.Versions...)
+			for _, marker := range title.Markers {
+				haystack = append(haystack, marker.Emoji)
+			}
+		}
+
+		if !containsAny(haystack, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func FilterEntries(entries []Entry, query string) []Entry {
+	if strings.TrimSpace(query) == "" {
+		return entries
+	}
+	var filtered []Entry
+	for _, entry := range entries {
+		if MatchesFilter(entry, query) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func containsAny(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if strings.Contains(strings.ToLower(s), needle) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package filemgmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTitle(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Title
+	}{
+		{"Plain Song", Title{Base: "Plain Song"}},
+		{
+			"⭐ Song [V2] (Other Name) (feat. A & B) (prod. C)",
+			Title{
+				Base:      "Song",
+				AltTitles: []string{"Other Name"},
+				Features:  []string{"A", "B"},
+				Producers: []string{"C"},
+				Versions:  []string{"V2"},
+				Markers:   []Marker{{"⭐", "best"}},
+			},
+		},
+		{
+			"Song (Alt One / Alt Two) (ft. X, Y and Z)",
+			Title{Base: "Song", AltTitles: []string{"Alt One", "Alt Two"}, Features: []string{"X", "Y", "Z"}},
+		},
+		{"Song (prod. by A x B) [OG]", Title{Base: "Song", Producers: []string{"A", "B"}, Versions: []string{"OG"}}},
+		{"Song (Demo) (Version 3)", Title{Base: "Song", Versions: []string{"Demo", "Version 3"}}},
+		{"🗑️ 🤖 Song", Title{Base: "Song", Markers: []Marker{{"🗑️", "worst"}, {"🤖", "ai"}}}},
+		{"Song ()", Title{Base: "Song"}},
+	}
+	for _, tt := range tests {
+		tt.want.Raw = tt.raw
+		if got := ParseTitle(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTitle(%q) =\n%+v\nwant\n%+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesFilter(t *testing.T) {
+	entry := Entry{Name: "⭐ Song [V2] (Other Name) (feat. Someone) (prod. Producer)"}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"song", true},
+		{"other", true},
+		{"feat:some", true},
+		{"feat:producer", false},
+		{"prod:prod", true},
+		{"ver:v2", true},
+		{"ver:v3", false},
+		{"alt:other", true},
+		{"tag:best", true},
+		{"tag:worst", false},
+		{"song feat:someone", true},
+		{"song missing", false},
+	}
+	for _, tt := range tests {
+		if got := MatchesFilter(entry, tt.query); got != tt.want {
+			t.Errorf("MatchesFilter(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package filemgmt
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Field identifies one of the columns a tracker sheet is expected to carry,
+// independent of where that column sits in a given CSV export.
+type Field int
+
+const (
+	FieldEra Field = iota
+	FieldName
+	FieldNotes
+	FieldTrackLength
+	FieldFileDate
+	FieldLeakDate
+	FieldType
+	FieldQuality
+	FieldLinks
+	fieldCount
+)
+
+var fieldNames = [fieldCount]string{
+	"Era",
+	"Name",
+	"Notes",
+	"Track Length",
+	"File Date",
+	"Leak Date",
+	"Type",
+	"Quality",
+	"Link(s)",
+}
+
+// header aliases, already normalized with normalizeHeader
+var fieldAliases = map[Field][]string{
+	FieldEra:         {"era", "album", "project"},
+	FieldName:        {"name", "title", "song", "song name", "track", "track name"},
+	FieldNotes:       {"notes", "note", "description", "info"},
+	FieldTrackLength: {"track length", "length", "duration", "runtime"},
+	FieldFileDate:    {"file date", "date", "recorded", "date recorded", "recording date"},
+	FieldLeakDate:    {"leak date", "leaked", "date leaked", "release date"},
+	FieldType:        {"type", "available length", "availability", "file type"},
+	FieldQuality:     {"quality"},
+	FieldLinks:       {"links", "link", "url", "urls", "download", "downloads"},
+}
+
+func (f Field) String() string {
+	if f < 0 || f >= fieldCount {
+		return ""
+	}
+	return fieldNames[f]
+}
+
+type Entry struct {
+	Era         string
+	Name        string
+	Notes       string
+	TrackLength string
+	FileDate    string
+	LeakDate    string
+	Type        string
+	Quality     string
+	Links       string
+
+	// Line is the 1-based line of the record in the source CSV.
+	Line int
+}
+
+func (e Entry) Value(f Field) string {
+	switch f {
+	case FieldEra:
+		return e.Era
+	case FieldName:
+		return e.Name
+	case FieldNotes:
+		return e.Notes
+	case FieldTrackLength:
+		return e.TrackLength
+	case FieldFileDate:
+		return e.FileDate
+	case FieldLeakDate:
+		return e.LeakDate
+	case FieldType:
+		return e.Type
+	case FieldQuality:
+		return e.Quality
+	case FieldLinks:
+		return e.Links
+	}
+	return ""
+}
+
+func (e *Entry) set(f Field, value string) {
+	switch f {
+	case FieldEra:
+		e.Era = value
+	case FieldName:
+		e.Name = value
+	case FieldNotes:
+		e.Notes = value
+	case FieldTrackLength:
+		e.TrackLength = value
+	case FieldFileDate:
+		e.FileDate = value
+	case FieldLeakDate:
+		e.LeakDate = value
+	case FieldType:
+		e.Type = value
+	case FieldQuality:
+		e.Quality = value
+	case FieldLinks:
+		e.Links = value
+	}
+}
+
+type Tracker struct {
+	// Tab is the name of the sheet tab this tracker was read from.
+	Tab string
+	// Metadata holds the banner rows found above the header row, such as
+	// the tracker title, legend or "last updated" notes.
+	Metadata []string
+	Headers  []string
+	// Columns maps each field to its column index in Headers, fields that
+	// the sheet doesn't have are left out.
+	Columns  map[Field]int
+	Eras     []Era
+	Entries  []Entry
+	Warnings []ParseWarning
+}
+
+func (t Tracker) Has(f Field) bool {
+	_, ok := t.Columns[f]
+	return ok
+}
+
+// Header returns the sheet's own title for a field, or the default one.
+func (t Tracker) Header(f Field) string {
+	if i, ok := t.Columns[f]; ok && i < len(t.Headers) && strings.TrimSpace(t.Headers[i]) != "" {
+		return strings.TrimSpace(t.Headers[i])
+	}
+	return f.String()
+}
+
+// MapColumns works out which column holds which field from the header row.
+// Columns that can't be matched by name fall back to the classic layout
+// (era first, name second, links last) when that slot is still free.
+func MapColumns(headers []string) map[Field]int {
+	columns := make(map[Field]int)
+	taken := make(map[int]bool)
+
+	for i, header := range headers {
+		normalized := normalizeHeader(header)
+		if normalized == "" {
+			continue
+		}
+		for f := Field(0); f < fieldCount; f++ {
+			if _, ok := columns[f]; ok {
+				continue
+			}
+			if matchesAlias(normalized, fieldAliases[f]) {
+				columns[f] = i
+				taken[i] = true
+				break
+			}
+		}
+	}
+
+	// in order, so a column both could claim always goes to the same field
+	fallbacks := []struct {
+		field Field
+		index int
+	}{
+		{FieldEra, 0},
+		{FieldName, 1},
+		{FieldLinks, len(headers) - 1},
+	}
+	for _, fb := range fallbacks {
+		if _, ok := columns[fb.field]; ok || fb.index < 0 || fb.index >= len(headers) || taken[fb.index] {
+			continue
+		}
+		columns[fb.field] = fb.index
+		taken[fb.index] = true
+	}
+
+	return columns
+}
+
+func matchesAlias(normalized string, aliases []string) bool {
+	for _, alias := range aliases {
+		if normalized == alias {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// how many rows from the top are considered when looking for the header
+const headerScanRows = 20
+
+// DetectHeaderRow returns the index of the record that looks most like the
+// header row, i.e. the one naming the most known fields. Trackers without a
+// recognisable header fall back to the first record.
+func DetectHeaderRow(records [][]string) int {
+	best, bestScore := 0, 1
+	for i := 0; i < len(records) && i < headerScanRows; i++ {
+		if score := headerScore(records[i]); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+func headerScore(record []string) int {
+	found := make(map[Field]bool)
+	for _, cell := range record {
+		normalized := normalizeHeader(cell)
+		if normalized == "" {
+			continue
+		}
+		for f := Field(0); f < fieldCount; f++ {
+			if !found[f] && matchesAlias(normalized, fieldAliases[f]) {
+				found[f] = true
+				break
+			}
+		}
+	}
+	return len(found)
+}
+
+func metadataLines(records [][]string) []string {
+	var lines []string
+	for _, record := range records {
+		var cells []string
+		for _, cell := range record {
+			if cell = strings.Join(strings.Fields(cell), " "); cell != "" {
+				cells = append(cells, cell)
+			}
+		}
+		if len(cells) > 0 {
+			lines = append(lines, strings.Join(cells, " "))
+		}
+	}
+	return lines
+}
+
+// ParseTracker builds the tracker model from raw records. The header row is
+// detected with DetectHeaderRow and anything above it is kept as metadata.
+func ParseTracker(records [][]string) Tracker {
+	return parseTracker(records, nil, nil)
+}
+
+func parseTracker(records [][]string, lines []int, mapping ColumnMapping) Tracker {
+	var t Tracker
+	if len(records) == 0 {
+		t.Warnings = append(t.Warnings, ParseWarning{Message: "file has no rows"})
+		return t
+	}
+
+	lineOf := func(i int) int {
+		if i < len(lines) {
+			return lines[i]
+		}
+		return i + 1
+	}
+
+	headerRow := DetectHeaderRow(records)
+	t.Metadata = metadataLines(records[:headerRow])
+	t.Headers = records[headerRow]
+	t.Columns = MapColumns(t.Headers)
+	mapping.apply(t.Headers, t.Columns)
+	if headerScore(t.Headers) < 2 {
+		t.Warnings = append(t.Warnings, ParseWarning{Line: lineOf(headerRow), Message: "no recognisable header row, using the first row"})
+	}
+
+	for i, record := range records[headerRow+1:] {
+		entry := Entry{Line: lineOf(headerRow + i + 1)}
+		for f, col := range t.Columns {
+			if col < len(record) {
+				entry.set(f, strings.TrimSpace(record[col]))
+			}
+		}
+		if len(record) != len(t.Headers) && strings.TrimSpace(strings.Join(record, "")) != "" {
+			t.Warnings = append(t.Warnings, ParseWarning{
+				Line:    entry.Line,
+				Message: fmt.Sprintf("row has %d fields, header has %d", len(record), len(t.Headers)),
+			})
+		}
+
+		if t.isEraRow(entry) {
+			t.Eras = append(t.Eras, ParseEraRow(entry))
+			continue
+		}
+		if entry.Name == "" && entry.Links == "" {
+			continue
+		}
+		t.Entries = append(t.Entries, entry)
+	}
+
+	for _, entry := range t.Entries {
+		idx := t.eraIndex(entry.Era)
+		if idx == -1 {
+			if entry.Era == "" {
+				continue
+			}
+			t.Eras = append(t.Eras, Era{Name: FormatTitle(entry.Era)})
+			idx = len(t.Eras) - 1
+		}
+		t.Eras[idx].Entries = append(t.Eras[idx].Entries, entry)
+	}
+
+	return t
+}
+
+func (t Tracker) eraIndex(name string) int {
+	caseUpper := strings.ToUpper(FormatTitle(name))
+	for i := range t.Eras {
+		if strings.ToUpper(t.Eras[i].Name) == caseUpper {
+			return i
+		}
+	}
+	return -1
+}
+
+// era summary rows carry the file counts in the era column and the era name
+// in the name column, but never a quality or a link. Sheets without a quality
+// column have unlinked songs too, so there the counts have to be there.
+func (t Tracker) isEraRow(e Entry) bool {
+	if e.Links != "" || len(e.Era) <= 1 || len(e.Name) <= 1 {
+		return false
+	}
+	if t.Has(FieldQuality) {
+		return e.Quality == ""
+	}
+	return isEraSummary(e.Era)
+}
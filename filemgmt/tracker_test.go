@@ -0,0 +1,174 @@
+package filemgmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    map[Field]int
+	}{
+		{
+			name:    "classic layout",
+			headers: []string{"Era", "Name", "Notes", "Track Length", "File Date", "Leak Date", "Type", "Quality", "Link(s)"},
+			want: map[Field]int{
+				FieldEra: 0, FieldName: 1, FieldNotes: 2, FieldTrackLength: 3, FieldFileDate: 4,
+				FieldLeakDate: 5, FieldType: 6, FieldQuality: 7, FieldLinks: 8,
+			},
+		},
+		{
+			name:    "reordered with aliases",
+			headers: []string{"Song Name", "Album", "Length", "URL"},
+			want:    map[Field]int{FieldName: 0, FieldEra: 1, FieldTrackLength: 2, FieldLinks: 3},
+		},
+		{
+			name:    "case and punctuation",
+			headers: []string{" ERA ", "name:", "LINK(S)"},
+			want:    map[Field]int{FieldEra: 0, FieldName: 1, FieldLinks: 2},
+		},
+		{
+			name:    "unnamed columns fall back to the classic slots",
+			headers: []string{"", "", "Notes", ""},
+			want:    map[Field]int{FieldEra: 0, FieldName: 1, FieldNotes: 2, FieldLinks: 3},
+		},
+		{
+			name:    "fallbacks skip columns that are taken",
+			headers: []string{"Notes", "", "Quality"},
+			want:    map[Field]int{FieldNotes: 0, FieldName: 1, FieldQuality: 2},
+		},
+		{
+			// two columns: era and links would both want the last one
+			name:    "colliding fallbacks go to the earlier field",
+			headers: []string{"Quality", ""},
+			want:    map[Field]int{FieldQuality: 0, FieldName: 1},
+		},
+		{
+			name:    "only the first match counts",
+			headers: []string{"Name", "Title", "Links"},
+			want:    map[Field]int{FieldName: 0, FieldLinks: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// run a few times, map order must not matter
+			for range 20 {
+				if got := MapColumns(tt.headers); !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("MapColumns(%q) = %v, want %v", tt.headers, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectHeaderRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]string
+		want    int
+	}{
+		{"header first", [][]string{{"Era", "Name", "Links"}, {"A", "Song", "x"}}, 0},
+		{
+			"banner rows above",
+			[][]string{
+				{"Some Artist Tracker", "", ""},
+				{"Last updated 01/02/2024", "", ""},
+				{"Era", "Name", "Notes", "Link(s)"},
+				{"A", "Song", "", "https://example.com"},
+			},
+			2,
+		},
+		{"nothing recognisable", [][]string{{"foo", "bar"}, {"baz", "qux"}}, 0},
+		{"one known field isn't enough", [][]string{{"Name", "x"}, {"Era", "Name"}}, 1},
+		{"empty", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectHeaderRow(tt.records); got != tt.want {
+				t.Errorf("DetectHeaderRow = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTracker(t *testing.T) {
+	records := [][]string{
+		{"My Tracker"},
+		{"Era", "Name", "Notes", "Quality", "Link(s)"},
+		{"2 OG File(s)\n1 Snippet(s)", "First Era\n(2019 - 2020)", "The first one", "", ""},
+		{"First Era", "Song A", "", "CD Quality", "https://example.com/a.mp3"},
+		{"First Era", "Song B", "", "Low Quality", ""},
+		{"Second Era", "Song C", "", "High Quality", "https://example.com/c.mp3"},
+		{"", "", "", "", ""},
+	}
+	tr := ParseTracker(records)
+
+	if !reflect.DeepEqual(tr.Metadata, []string{"My Tracker"}) {
+		t.Errorf("metadata = %q", tr.Metadata)
+	}
+	if len(tr.Entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(tr.Entries))
+	}
+	if tr.Entries[0].Line != 4 || tr.Entries[2].Line != 6 {
+		t.Errorf("lines = %d, %d", tr.Entries[0].Line, tr.Entries[2].Line)
+	}
+	if len(tr.Eras) != 2 {
+		t.Fatalf("got %d eras, want 2", len(tr.Eras))
+	}
+	first := tr.Eras[0]
+	if first.Name != "First Era" || first.TimeFrame != "2019 - 2020" || first.Description != "The first one" {
+		t.Errorf("first era = %+v", first)
+	}
+	if len(first.Entries) != 2 {
+		t.Errorf("first era has %d entries, want 2", len(first.Entries))
+	}
+	if tr.Eras[1].Name != "Second Era" || len(tr.Eras[1].Entries) != 1 {
+		t.Errorf("second era = %+v", tr.Eras[1])
+	}
+}
+
+func TestParseTrackerWithoutQuality(t *testing.T) {
+	records := [][]string{
+		{"Era", "Name", "Notes", "Link(s)"},
+		{"3 OG File(s), 1 Full", "Some Era (2020)", "", ""},
+		{"Some Era", "Linked Song", "", "https://example.com/a.mp3"},
+		// unlinked songs are still songs without a quality column to tell
+		{"Some Era", "Unlinked Song", "", ""},
+		{"808s & Heartbreak", "Love Lockdown", "", ""},
+	}
+	tr := ParseTracker(records)
+
+	var names []string
+	for _, entry := range tr.Entries {
+		names = append(names, entry.Name)
+	}
+	if want := []string{"Linked Song", "Unlinked Song", "Love Lockdown"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("entries = %q, want %q", names, want)
+	}
+	var eras []string
+	for _, era := range tr.Eras {
+		eras = append(eras, era.Name)
+	}
+	if want := []string{"Some Era", "808s & Heartbreak"}; !reflect.DeepEqual(eras, want) {
+		t.Fatalf("eras = %q, want %q", eras, want)
+	}
+	if len(tr.Eras[0].Counts) != 2 {
+		t.Errorf("counts = %v", tr.Eras[0].Counts)
+	}
+}
+
+func TestParseTrackerWarnings(t *testing.T) {
+	if tr := ParseTracker(nil); len(tr.Warnings) != 1 {
+		t.Errorf("empty file warnings = %v", tr.Warnings)
+	}
+
+	tr := ParseTracker([][]string{
+		{"Era", "Name", "Links"},
+		{"A", "Song", "https://example.com", "extra"},
+	})
+	if len(tr.Warnings) != 1 || tr.Warnings[0].Line != 2 {
+		t.Errorf("warnings = %v, want one for line 2", tr.Warnings)
+	}
+}
@@ -0,0 +1,205 @@
+package filemgmt
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Duration struct {
+	Raw   string
+	Value time.Duration
+	Known bool
+}
+
+func (d Duration) String() string {
+	if !d.Known {
+		return d.Raw
+	}
+	total := int(d.Value.Seconds())
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total%3600/60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+var durationRe = regexp.MustCompile(`\b(?:(\d+):)?(\d{1,2}):(\d{2})\b`)
+
+// ParseDuration reads track lengths like "3:21" or "1:02:03". Placeholders
+// such as "?:??" or "N/A" come back with Known set to false.
+func ParseDuration(raw string) Duration {
+	d := Duration{Raw: strings.TrimSpace(raw)}
+	match := durationRe.FindStringSubmatch(d.Raw)
+	if match == nil {
+		return d
+	}
+
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.Atoi(match[3])
+	if seconds >= 60 {
+		return d
+	}
+	d.Value = time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	d.Known = d.Value > 0
+	return d
+}
+
+type DatePrecision int
+
+const (
+	DateUnknown DatePrecision = iota
+	DateYear
+	DateMonth
+	DateDay
+)
+
+type Date struct {
+	Raw       string
+	Time      time.Time
+	Precision DatePrecision
+}
+
+func (d Date) Known() bool {
+	return d.Precision != DateUnknown
+}
+
+func (d Date) String() string {
+	switch d.Precision {
+	case DateDay:
+		return d.Time.Format("2006-01-02")
+	case DateMonth:
+		return d.Time.Format("2006-01")
+	case DateYear:
+		return d.Time.Format("2006")
+	}
+	return d.Raw
+}
+
+var dateLayouts = []struct {
+	layout    string
+	precision DatePrecision
+}{
+	{"01/02/2006", DateDay},
+	{"1/2/2006", DateDay},
+	{"01/02/06", DateDay},
+	{"1/2/06", DateDay},
+	{"2006-01-02", DateDay},
+	{"2006/01/02", DateDay},
+	{"Jan 2, 2006", DateDay},
+	{"January 2, 2006", DateDay},
+	{"Jan 2 2006", DateDay},
+	{"January 2 2006", DateDay},
+	{"2 Jan 2006", DateDay},
+	{"2 January 2006", DateDay},
+	{"01/2006", DateMonth},
+	{"1/2006", DateMonth},
+	{"2006-01", DateMonth},
+	{"Jan 2006", DateMonth},
+	{"January 2006", DateMonth},
+	{"Jan, 2006", DateMonth},
+	{"January, 2006", DateMonth},
+	{"2006", DateYear},
+}
+
+var (
+	partialMonthRe = regexp.MustCompile(`^(\d{1,2})/\?\?/(\d{4})$`)
+	yearRe         = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+	ordinalRe      = regexp.MustCompile(`(\d)(st|nd|rd|th)\b`)
+)
+
+// ParseDate understands the date formats trackers tend to use, including
+// partial ones ("03/??/2019", "2019", "Early 2019"). Anything it can't read,
+// like "Unknown" or "N/A", comes back with DateUnknown precision.
+func ParseDate(raw string) Date {
+	d := Date{Raw: strings.TrimSpace(raw)}
+
+	value := d.Raw
+	if idx := strings.Index(value, "\n"); idx != -1 {
+		value = value[:idx]
+	}
+	value = ordinalRe.ReplaceAllString(strings.TrimSpace(value), "$1")
+	if value == "" {
+		return d
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout.layout, value); err == nil {
+			d.Time, d.Precision = t, layout.precision
+			return d
+		}
+	}
+
+	if match := partialMonthRe.FindStringSubmatch(value); match != nil {
+		if t, err := time.Parse("1/2006", match[1]+"/"+match[2]); err == nil {
+			d.Time, d.Precision = t, DateMonth
+			return d
+		}
+	}
+
+	if year := yearRe.FindString(value); year != "" {
+		t, _ := time.Parse("2006", year)
+		d.Time, d.Precision = t, DateYear
+	}
+	return d
+}
+
+func (e Entry) ParsedLength() Duration {
+	return ParseDuration(e.TrackLength)
+}
+
+func (e Entry) ParsedFileDate() Date {
+	return ParseDate(e.FileDate)
+}
+
+func (e Entry) ParsedLeakDate() Date {
+	return ParseDate(e.LeakDate)
+}
+
+// SortEntries sorts a copy of entries by the given field. Lengths and dates
+// are compared by their parsed values, unknown values always sort last.
+func SortEntries(entries []Entry, field Field, descending bool) []Entry {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		cmp, iKnown, jKnown := compareField(sorted[i], sorted[j], field)
+		if iKnown != jKnown {
+			return iKnown
+		}
+		if descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	return sorted
+}
+
+func compareField(a, b Entry, field Field) (int, bool, bool) {
+	switch field {
+	case FieldTrackLength:
+		da, db := a.ParsedLength(), b.ParsedLength()
+		return compareInts(int64(da.Value), int64(db.Value)), da.Known, db.Known
+	case FieldFileDate, FieldLeakDate:
+		da, db := ParseDate(a.Value(field)), ParseDate(b.Value(field))
+		return da.Time.Compare(db.Time), da.Known(), db.Known()
+	case FieldName:
+		na, nb := strings.ToLower(a.Title().Base), strings.ToLower(b.Title().Base)
+		return strings.Compare(na, nb), na != "", nb != ""
+	}
+	va, vb := strings.ToLower(a.Value(field)), strings.ToLower(b.Value(field))
+	return strings.Compare(va, vb), va != "", vb != ""
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
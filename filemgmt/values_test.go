@@ -0,0 +1,99 @@
+package filemgmt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		raw   string
+		want  time.Duration
+		known bool
+	}{
+		{"3:21", 3*time.Minute + 21*time.Second, true},
+		{" 0:45 ", 45 * time.Second, true},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"12:00 (snippet)", 12 * time.Minute, true},
+		{"?:??", 0, false},
+		{"N/A", 0, false},
+		{"0:00", 0, false},
+		{"3:75", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got := ParseDuration(tt.raw)
+		if got.Value != tt.want || got.Known != tt.known {
+			t.Errorf("ParseDuration(%q) = %v known %v, want %v known %v", tt.raw, got.Value, got.Known, tt.want, tt.known)
+		}
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"3:21", "3:21"},
+		{"1:02:03", "1:02:03"},
+		{"63:00", "1:03:00"},
+		{"?:??", "?:??"},
+	}
+	for _, tt := range tests {
+		if got := ParseDuration(tt.raw).String(); got != tt.want {
+			t.Errorf("ParseDuration(%q).String() = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		raw       string
+		want      string
+		precision DatePrecision
+	}{
+		{"03/14/2019", "2019-03-14", DateDay},
+		{"3/4/19", "2019-03-04", DateDay},
+		{"2019-03-14", "2019-03-14", DateDay},
+		{"March 14th, 2019", "2019-03-14", DateDay},
+		{"Mar 1st 2019", "2019-03-01", DateDay},
+		{"14 March 2019", "2019-03-14", DateDay},
+		{"03/??/2019", "2019-03", DateMonth},
+		{"March 2019", "2019-03", DateMonth},
+		{"2019-03", "2019-03", DateMonth},
+		{"2019", "2019", DateYear},
+		{"Early 2019", "2019", DateYear},
+		{"03/14/2019\n(rough guess)", "2019-03-14", DateDay},
+		{"Unknown", "Unknown", DateUnknown},
+		{"N/A", "N/A", DateUnknown},
+		{"", "", DateUnknown},
+	}
+	for _, tt := range tests {
+		got := ParseDate(tt.raw)
+		if got.Precision != tt.precision || got.String() != tt.want {
+			t.Errorf("ParseDate(%q) = %q precision %d, want %q precision %d", tt.raw, got.String(), got.Precision, tt.want, tt.precision)
+		}
+	}
+}
+
+func TestSortEntriesUnknownLast(t *testing.T) {
+	entries := []Entry{
+		{Name: "B", TrackLength: "?:??"},
+		{Name: "C", TrackLength: "4:00"},
+		{Name: "A", TrackLength: "2:00"},
+	}
+	for _, descending := range []bool{false, true} {
+		sorted := SortEntries(entries, FieldTrackLength, descending)
+		var names string
+		for _, entry := range sorted {
+			names += entry.Name
+		}
+		want := "ACB"
+		if descending {
+			want = "CAB"
+		}
+		if names != want {
+			t.Errorf("descending %v: got %s, want %s", descending, names, want)
+		}
+	}
+}
@@ -101,6 +101,19 @@ var (
 				Background(ColorAccent).
 				Bold(false)
 
+	PanelStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(ColorTableBorder).
+			Foreground(ColorText).
+			Padding(0, 1)
+
+	PanelTitle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(ColorPrimary)
+
+	PanelLabel = lipgloss.NewStyle().
+			Foreground(ColorHighlight)
+
 	CsvTableSelectedStyleAlt = lipgloss.NewStyle().
 					Foreground(ColorAltText).
 					Background(ColorAltBackground).